@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fotoetienne/gqai/graphql"
+	"github.com/spf13/cobra"
+)
+
+var manifestFormat string
+var manifestOutput string
+
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Write the persisted document manifest of the operations",
+	Long: `Writes the manifest of every operation served as a tool, identified by the
+SHA-256 hash of the document gqai sends for it, to register them as trusted
+documents with the GraphQL server. Formats: apollo, hive or relay.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		projects := config.Projects()
+		if len(projects) != 1 {
+			fmt.Println("Config has multiple projects, select one with --project")
+			os.Exit(1)
+		}
+
+		ops, err := graphql.LoadProjectOperations(cmd.Context(), projects[0])
+		if err != nil {
+			fmt.Println("Error loading operations:", err)
+			os.Exit(1)
+		}
+
+		manifest, err := graphql.Manifest(ops, manifestFormat)
+		if err != nil {
+			fmt.Println("Error building manifest:", err)
+			os.Exit(1)
+		}
+		out, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			fmt.Println("Error encoding manifest:", err)
+			os.Exit(1)
+		}
+
+		if manifestOutput == "-" {
+			fmt.Println(string(out))
+			return
+		}
+		if err := os.WriteFile(manifestOutput, append(out, '\n'), 0644); err != nil {
+			fmt.Println("Error writing manifest:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Manifest of %d operation(s) written to %s\n", len(ops), manifestOutput)
+	},
+}
+
+func init() {
+	manifestCmd.Flags().StringVarP(&manifestFormat, "format", "f", graphql.ManifestApollo, "Manifest format: apollo, hive or relay")
+	manifestCmd.Flags().StringVarP(&manifestOutput, "output", "o", "-", "File to write the manifest to, - for stdout")
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/fotoetienne/gqai/mcp"
@@ -16,6 +17,7 @@ import (
 
 var config *graphql.GraphQLConfig
 var configPath string
+var projectName string
 var host string
 var port int
 
@@ -28,6 +30,7 @@ var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run gqai as an MCP server in stdin/stdout mode",
 	Run: func(cmd *cobra.Command, args []string) {
+		checkOperations()
 		mcp.RunMCPStdIO(config)
 	},
 }
@@ -36,6 +39,7 @@ var runSSECmd = &cobra.Command{
 	Use:   "run-sse",
 	Short: "Run gqai as an MCP server with SSE transport",
 	Run: func(cmd *cobra.Command, args []string) {
+		checkOperations()
 		addr := fmt.Sprintf("%s:%d", host, port)
 		mcp.RunMCPSSE(config, addr)
 	},
@@ -45,6 +49,7 @@ var runStreamableHTTPCmd = &cobra.Command{
 	Use:   "run-streamable-http",
 	Short: "Run gqai as an MCP server with streamable HTTP transport",
 	Run: func(cmd *cobra.Command, args []string) {
+		checkOperations()
 		addr := fmt.Sprintf("%s:%d", host, port)
 		mcp.RunMCPStreamableHTTP(config, addr)
 	},
@@ -75,7 +80,7 @@ var toolsCallCmd = &cobra.Command{
 			},
 		}
 
-		var resp = mcp.ToolsCall(request, config)
+		var resp = mcp.ToolsCall(context.Background(), request, config)
 
 		var error = resp.Error
 		if error != nil {
@@ -86,6 +91,10 @@ var toolsCallCmd = &cobra.Command{
 		var result = resp.Result
 		out, _ := json.MarshalIndent(result, "", "  ")
 		fmt.Println(string(out))
+
+		if result, ok := result.(mcp.CallToolResult); ok && result.IsError {
+			os.Exit(1)
+		}
 	},
 }
 
@@ -93,7 +102,7 @@ var toolsListCmd = &cobra.Command{
 	Use:   "tools/list",
 	Short: "List available tools",
 	Run: func(cmd *cobra.Command, args []string) {
-		tools, err := tool.ToolsFromConfig(config)
+		tools, err := tool.ToolsFromConfig(cmd.Context(), config)
 		if err != nil {
 			fmt.Println("Error loading tools:", err)
 			os.Exit(1)
@@ -114,7 +123,7 @@ var describeCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		toolName := args[0]
-		tools, err := tool.ToolsFromConfig(config)
+		tools, err := tool.ToolsFromConfig(cmd.Context(), config)
 		if err != nil {
 			fmt.Println("Error loading tools:", err)
 			os.Exit(1)
@@ -136,6 +145,7 @@ var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Serve tools over HTTP",
 	Run: func(cmd *cobra.Command, args []string) {
+		checkOperations()
 		r := mux.NewRouter()
 
 		// List tools
@@ -147,16 +157,28 @@ var serveCmd = &cobra.Command{
 		// Tool specific handler
 		r.HandleFunc("/tools/{toolName}", serveHandler).Methods("POST")
 
+		// Circuit breaker states of the endpoints
+		r.HandleFunc("/health", healthHandler).Methods("GET")
+
 		addr := fmt.Sprintf("%s:%d", host, port)
 		fmt.Printf("Serving on http://%s\n", addr)
 		log.Fatal(http.ListenAndServe(addr, r))
 	},
 }
 
+// checkOperations loads the operations once before a server starts,
+// so that invalid documents are reported up front instead of on the first request
+func checkOperations() {
+	if _, err := graphql.LoadOperations(context.Background(), config); err != nil {
+		log.Fatalf("Error loading operations: %v", err)
+	}
+}
+
 func Execute() {
 	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", ".graphqlrc.yml", "Path to .graphqlrc.yml")
 	rootCmd.PersistentFlags().StringVarP(&host, "host", "H", "localhost", "Host to bind to")
 	rootCmd.PersistentFlags().IntVarP(&port, "port", "p", 8080, "Port to bind to")
+	rootCmd.PersistentFlags().StringVar(&projectName, "project", "", "Only serve the named project from a multi-project config")
 
 	cobra.OnInitialize(func() {
 		var err error
@@ -164,6 +186,12 @@ func Execute() {
 		if err != nil {
 			log.Fatalf("Error loading config: %v", err)
 		}
+		if projectName != "" {
+			config, err = config.SelectProject(projectName)
+			if err != nil {
+				log.Fatalf("Error selecting project: %v", err)
+			}
+		}
 	})
 
 	rootCmd.AddCommand(runCmd)
@@ -173,5 +201,9 @@ func Execute() {
 	rootCmd.AddCommand(toolsListCmd)
 	rootCmd.AddCommand(describeCmd)
 	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(manifestCmd)
 	rootCmd.Execute()
 }
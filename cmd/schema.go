@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fotoetienne/gqai/graphql"
+	"github.com/spf13/cobra"
+)
+
+var schemaOutput string
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Work with the GraphQL schema of a project",
+}
+
+var schemaPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Introspect the schema endpoint and write it as SDL",
+	Run: func(cmd *cobra.Command, args []string) {
+		projects := config.Projects()
+		if len(projects) != 1 {
+			fmt.Println("Config has multiple projects, select one with --project")
+			os.Exit(1)
+		}
+
+		sdl, err := graphql.IntrospectSDL(cmd.Context(), projects[0], true)
+		if err != nil {
+			fmt.Println("Error introspecting schema:", err)
+			os.Exit(1)
+		}
+
+		if schemaOutput == "-" {
+			fmt.Print(sdl)
+			return
+		}
+
+		if err := os.WriteFile(schemaOutput, []byte(sdl), 0644); err != nil {
+			fmt.Println("Error writing schema:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Schema written to %s\n", schemaOutput)
+	},
+}
+
+func init() {
+	schemaPullCmd.Flags().StringVarP(&schemaOutput, "output", "o", "schema.graphql", "File to write the SDL to, - for stdout")
+	schemaCmd.AddCommand(schemaPullCmd)
+}
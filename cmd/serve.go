@@ -2,15 +2,17 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
 	"net/http"
 
+	"github.com/fotoetienne/gqai/graphql"
 	"github.com/fotoetienne/gqai/tool"
 )
 
 func listToolsHandler(w http.ResponseWriter, r *http.Request) {
-	tools, err := tool.ToolsFromConfig(config)
+	tools, err := tool.ToolsFromConfig(r.Context(), config)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error loading tools: %v", err), http.StatusInternalServerError)
 		return
@@ -35,16 +37,16 @@ func callToolHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Load tool
-	tool, err := tool.LoadTool(config, payload.ToolName)
+	tool, err := tool.LoadTool(r.Context(), config, payload.ToolName)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error loading tool: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	// Execute the tool
-	result, err := tool.Execute(payload.Input)
+	result, err := tool.Execute(r.Context(), payload.Input)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Execution error: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Execution error: %v", err), executionErrorStatus(err))
 		return
 	}
 
@@ -66,15 +68,15 @@ func serveHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Find the tool by name
 	toolName := mux.Vars(r)["name"]
-	tool, err := tool.LoadTool(config, toolName)
+	tool, err := tool.LoadTool(r.Context(), config, toolName)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error loading tool: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	result, err := tool.Execute(payload.Input)
+	result, err := tool.Execute(r.Context(), payload.Input)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Execution error: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Execution error: %v", err), executionErrorStatus(err))
 		return
 	}
 
@@ -83,3 +85,12 @@ func serveHandler(w http.ResponseWriter, r *http.Request) {
 		"output": result,
 	})
 }
+
+// executionErrorStatus returns the HTTP status of a failed tool call: invalid arguments are the caller's fault
+func executionErrorStatus(err error) int {
+	var variableErr *graphql.VariableError
+	if errors.As(err, &variableErr) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
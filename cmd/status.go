@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fotoetienne/gqai/graphql"
+	"github.com/spf13/cobra"
+)
+
+var statusServer string
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the GraphQL endpoints are available",
+	Long: `Sends a one-off query to the endpoint of every project and shows whether it answered.
+The circuit breakers live in the server process: with --server, shows those of a running
+"gqai serve" instead. Exits with status 1 when an endpoint is unavailable.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if statusServer != "" {
+			health, err := fetchHealth(statusServer)
+			if err != nil {
+				fmt.Println("Error fetching server health:", err)
+				os.Exit(1)
+			}
+			available := true
+			for _, state := range health.Circuits {
+				printCircuitState(state)
+				if state.State != graphql.CircuitClosed {
+					available = false
+				}
+			}
+			if !available {
+				os.Exit(1)
+			}
+			return
+		}
+
+		available := true
+		for _, project := range config.Projects() {
+			ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Second)
+			err := graphql.CheckEndpoint(ctx, project)
+			cancel()
+
+			endpoint, _ := project.Endpoint()
+			if err != nil {
+				fmt.Printf("%s: %s unreachable\n  %v\n", project.Name, endpoint, err)
+				available = false
+				continue
+			}
+			fmt.Printf("%s: %s reachable\n", project.Name, endpoint)
+		}
+		if !available {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	statusCmd.Flags().StringVar(&statusServer, "server", "", "URL of a running gqai serve, e.g. http://localhost:8080")
+}
+
+// healthResponse is the body of the /health endpoint of serve
+type healthResponse struct {
+	Status   string                 `json:"status"`
+	Circuits []graphql.CircuitState `json:"circuits"`
+}
+
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	health := healthResponse{Status: "ok", Circuits: graphql.CircuitStates()}
+	for _, state := range health.Circuits {
+		if state.State != graphql.CircuitClosed {
+			health.Status = "degraded"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(health)
+}
+
+func fetchHealth(server string) (*healthResponse, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimSuffix(server, "/") + "/health")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	var health healthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, fmt.Errorf("failed to parse health response: %w", err)
+	}
+	return &health, nil
+}
+
+func printCircuitState(state graphql.CircuitState) {
+	if state.Project != "" {
+		fmt.Printf("%s: ", state.Project)
+	}
+	fmt.Printf("%s circuit %s", state.Endpoint, state.State)
+	if state.RetryAt != nil {
+		fmt.Printf(" until %s", state.RetryAt.Format(time.RFC3339))
+	}
+	if state.Failures > 0 {
+		fmt.Printf(" (%d consecutive failures)", state.Failures)
+	}
+	fmt.Println()
+	if state.LastError != "" {
+		fmt.Printf("  %s\n", state.LastError)
+	}
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/fotoetienne/gqai/graphql"
+	"github.com/spf13/cobra"
+)
+
+var validateFormat string
+var validateStrict bool
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the operations of the config against the schema and the tool rules",
+	Long: `Parses every document, validates the operations against the schema and checks
+that they make usable tools. Exits with status 1 when errors are found
+(or warnings, with --strict).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		diagnostics, err := graphql.Lint(cmd.Context(), config)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error validating operations:", err)
+			os.Exit(2)
+		}
+
+		switch validateFormat {
+		case "text":
+			writeDiagnosticsText(diagnostics)
+		case "json":
+			writeJSON(map[string]any{"diagnostics": diagnostics})
+		case "sarif":
+			writeJSON(sarifLog(diagnostics))
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown format %q, use text, json or sarif\n", validateFormat)
+			os.Exit(2)
+		}
+
+		for _, diagnostic := range diagnostics {
+			if diagnostic.Severity == graphql.SeverityError || validateStrict {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	validateCmd.Flags().StringVarP(&validateFormat, "format", "f", "text", "Output format: text, json or sarif")
+	validateCmd.Flags().BoolVar(&validateStrict, "strict", false, "Exit with status 1 on warnings too")
+}
+
+func writeDiagnosticsText(diagnostics []graphql.Diagnostic) {
+	errorCount, warningCount := 0, 0
+	for _, d := range diagnostics {
+		location := d.File
+		if location == "" {
+			location = d.Project
+		}
+		if d.Line > 0 {
+			location = fmt.Sprintf("%s:%d:%d", location, d.Line, d.Column)
+		}
+		if location != "" {
+			location += ": "
+		}
+		fmt.Printf("%s%s: %s [%s]\n", location, d.Severity, d.Message, d.Rule)
+
+		if d.Severity == graphql.SeverityError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+	fmt.Printf("%d error(s), %d warning(s)\n", errorCount, warningCount)
+}
+
+func writeJSON(v any) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error serializing diagnostics:", err)
+		os.Exit(2)
+	}
+	fmt.Println(string(out))
+}
+
+// sarifLog converts diagnostics to a SARIF 2.1.0 log, the format read by code scanning tools
+func sarifLog(diagnostics []graphql.Diagnostic) map[string]any {
+	ruleIDs := make([]string, 0, len(graphql.LintRules))
+	for id := range graphql.LintRules {
+		ruleIDs = append(ruleIDs, id)
+	}
+	sort.Strings(ruleIDs)
+
+	rules := make([]map[string]any, 0, len(ruleIDs))
+	for _, id := range ruleIDs {
+		rules = append(rules, map[string]any{
+			"id":               id,
+			"shortDescription": map[string]any{"text": graphql.LintRules[id]},
+		})
+	}
+
+	results := make([]map[string]any, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		result := map[string]any{
+			"ruleId":  d.Rule,
+			"level":   d.Severity,
+			"message": map[string]any{"text": d.Message},
+		}
+		if d.File != "" {
+			location := map[string]any{
+				"artifactLocation": map[string]any{"uri": filepath.ToSlash(d.File)},
+			}
+			if d.Line > 0 {
+				location["region"] = map[string]any{"startLine": d.Line, "startColumn": d.Column}
+			}
+			result["locations"] = []map[string]any{{"physicalLocation": location}}
+		}
+		results = append(results, result)
+	}
+
+	return map[string]any{
+		"version": "2.1.0",
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"runs": []map[string]any{{
+			"tool": map[string]any{
+				"driver": map[string]any{
+					"name":           "gqai",
+					"informationUri": "https://github.com/fotoetienne/gqai",
+					"rules":          rules,
+				},
+			},
+			"results": results,
+		}},
+	}
+}
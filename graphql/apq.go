@@ -0,0 +1,149 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// APQOptions enable Automatic Persisted Queries, under `extensions.gqai.apq`:
+// operations are sent as a SHA-256 hash, and the full query only when the endpoint does not know the hash yet.
+type APQOptions struct {
+	Enabled bool `yaml:"enabled"`
+	GET     bool `yaml:"get"` // Send the hashes of registered queries as GET requests, so that they can be cached
+}
+
+// Errors returned by endpoints for persisted queries they cannot serve
+const (
+	persistedQueryNotFound     = "PersistedQueryNotFound"
+	persistedQueryNotSupported = "PersistedQueryNotSupported"
+)
+
+// apqEndpoint records what an endpoint is known to support
+type apqEndpoint struct {
+	mu          sync.Mutex
+	unsupported bool            // the endpoint answered PersistedQueryNotSupported
+	registered  map[string]bool // hashes the endpoint is known to have
+}
+
+// apqEndpoints are kept per endpoint URL
+var apqEndpoints = struct {
+	sync.Mutex
+	endpoints map[string]*apqEndpoint
+}{endpoints: map[string]*apqEndpoint{}}
+
+func apqEndpointFor(endpoint string) *apqEndpoint {
+	apqEndpoints.Lock()
+	defer apqEndpoints.Unlock()
+
+	e, ok := apqEndpoints.endpoints[endpoint]
+	if !ok {
+		e = &apqEndpoint{registered: map[string]bool{}}
+		apqEndpoints.endpoints[endpoint] = e
+	}
+	return e
+}
+
+func (e *apqEndpoint) isUnsupported() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.unsupported
+}
+
+func (e *apqEndpoint) setUnsupported() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.unsupported = true
+}
+
+func (e *apqEndpoint) isRegistered(hash string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.registered[hash]
+}
+
+func (e *apqEndpoint) setRegistered(hash string, registered bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if registered {
+		e.registered[hash] = true
+	} else {
+		delete(e.registered, hash)
+	}
+}
+
+// QueryHash returns the hex SHA-256 hash identifying a query document
+func QueryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// sendPersisted sends the request with the Apollo APQ protocol. The hash is sent first,
+// and the full query along with it when the endpoint answers PersistedQueryNotFound.
+// Endpoints answering PersistedQueryNotSupported get plain requests from then on.
+func (c *call) sendPersisted(ctx context.Context, request graphqlRequest) ([]byte, error) {
+	endpoint := apqEndpointFor(c.endpoint)
+	if endpoint.isUnsupported() {
+		return c.send(ctx, c.op.Method(), request)
+	}
+
+	hash := QueryHash(request.Query)
+	persisted := request
+	persisted.Query = ""
+	persisted.Extensions = map[string]any{
+		"persistedQuery": map[string]any{"version": 1, "sha256Hash": hash},
+	}
+
+	// Only hashes the endpoint is known to have are sent as GET, so that caches never keep a PersistedQueryNotFound
+	method := http.MethodPost
+	get := c.op.Project.Options.APQ.GET || c.op.Method() == http.MethodGet
+	if get && c.op.isQuery() && endpoint.isRegistered(hash) {
+		method = http.MethodGet
+	}
+
+	body, err := c.send(ctx, method, persisted)
+	switch persistedQueryError(body, err) {
+	case persistedQueryNotFound:
+		// The endpoint forgot or never saw the query: register it by sending it with its hash
+		endpoint.setRegistered(hash, false)
+		persisted.Query = request.Query
+		body, err = c.send(ctx, http.MethodPost, persisted)
+	case persistedQueryNotSupported:
+		endpoint.setUnsupported()
+		return c.send(ctx, c.op.Method(), request)
+	}
+	if err == nil {
+		endpoint.setRegistered(hash, true)
+	}
+	return body, err
+}
+
+// persistedQueryError returns the persisted query error of a response, if any.
+// Endpoints report them as GraphQL errors, with a 200 or an error status.
+func persistedQueryError(body []byte, err error) string {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		body = []byte(statusErr.Body)
+	} else if err != nil {
+		return ""
+	}
+
+	var resp response
+	if json.Unmarshal(body, &resp) != nil {
+		return ""
+	}
+	for _, e := range resp.Errors {
+		code, _ := e.Extensions["code"].(string)
+		switch {
+		case e.Message == persistedQueryNotFound || code == "PERSISTED_QUERY_NOT_FOUND":
+			return persistedQueryNotFound
+		case e.Message == persistedQueryNotSupported || code == "PERSISTED_QUERY_NOT_SUPPORTED":
+			return persistedQueryNotSupported
+		}
+	}
+	return ""
+}
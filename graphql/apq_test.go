@@ -0,0 +1,164 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// apqRequest is a request received by the test endpoints, from a POST body or GET parameters
+type apqRequest struct {
+	Method     string
+	Query      string
+	Hash       string
+	Variables  map[string]any
+	Extensions map[string]any
+}
+
+func readAPQRequest(t *testing.T, r *http.Request) apqRequest {
+	t.Helper()
+	var body struct {
+		Query      string         `json:"query"`
+		Variables  map[string]any `json:"variables"`
+		Extensions map[string]any `json:"extensions"`
+	}
+	if r.Method == http.MethodGet {
+		params := r.URL.Query()
+		body.Query = params.Get("query")
+		json.Unmarshal([]byte(params.Get("variables")), &body.Variables)
+		json.Unmarshal([]byte(params.Get("extensions")), &body.Extensions)
+	} else {
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("Invalid request body: %s", data)
+		}
+	}
+
+	request := apqRequest{Method: r.Method, Query: body.Query, Variables: body.Variables, Extensions: body.Extensions}
+	if persisted, ok := body.Extensions["persistedQuery"].(map[string]any); ok {
+		request.Hash, _ = persisted["sha256Hash"].(string)
+	}
+	return request
+}
+
+func TestExecuteAPQ(t *testing.T) {
+	// The endpoint behaves like Apollo Server: unknown hashes must be sent again with their query
+	registered := map[string]string{}
+	var requests []apqRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := readAPQRequest(t, r)
+		requests = append(requests, request)
+
+		w.Header().Set("Content-Type", "application/json")
+		if request.Query == "" {
+			if _, ok := registered[request.Hash]; !ok {
+				fmt.Fprint(w, `{"errors": [{"message": "PersistedQueryNotFound", "extensions": {"code": "PERSISTED_QUERY_NOT_FOUND"}}]}`)
+				return
+			}
+		} else if request.Hash != "" {
+			if QueryHash(request.Query) != request.Hash {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"errors": [{"message": "provided sha does not match query"}]}`)
+				return
+			}
+			registered[request.Hash] = request.Query
+		}
+		fmt.Fprintf(w, `{"data": {"film": %q}}`, request.Variables["id"])
+	}))
+	defer server.Close()
+
+	project := &GraphQLProject{Options: ProjectOptions{APQ: APQOptions{Enabled: true, GET: true}}}
+	op := &Operation{
+		Name:          "Film",
+		Query:         "query Film($id: ID!) { film(id: $id) }",
+		OperationType: "query",
+		Project:       project,
+	}
+	hash := QueryHash(op.Query)
+
+	for _, id := range []string{"1", "2"} {
+		result, err := Execute(context.Background(), server.URL, map[string]any{"id": id}, op, nil)
+		if err != nil {
+			t.Fatalf("Execute returned an error: %v", err)
+		}
+		if film := result.(map[string]any)["data"].(map[string]any)["film"]; film != id {
+			t.Errorf("Expected film %s, got %v", id, film)
+		}
+	}
+
+	// The first call registers the query, the second sends only its hash, with GET
+	expected := []string{
+		"POST hash " + hash,
+		"POST query and hash " + hash,
+		"GET hash " + hash,
+	}
+	var got []string
+	for _, request := range requests {
+		switch {
+		case request.Query == "":
+			got = append(got, request.Method+" hash "+request.Hash)
+		case request.Hash != "":
+			got = append(got, request.Method+" query and hash "+request.Hash)
+		default:
+			got = append(got, request.Method+" query")
+		}
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected requests:\n got: %q\nwant: %q", got, expected)
+	}
+
+	// Mutations are never sent as GET
+	requests = nil
+	mutation := &Operation{
+		Name:          "Rate",
+		Query:         "mutation Rate($id: ID!) { rate(id: $id) }",
+		OperationType: "mutation",
+		Project:       project,
+	}
+	registered[QueryHash(mutation.Query)] = mutation.Query
+	apqEndpointFor(server.URL).setRegistered(QueryHash(mutation.Query), true)
+	if _, err := Execute(context.Background(), server.URL, map[string]any{"id": "1"}, mutation, nil); err != nil {
+		t.Fatalf("Execute returned an error: %v", err)
+	}
+	if len(requests) != 1 || requests[0].Method != http.MethodPost || requests[0].Query != "" {
+		t.Errorf("Expected the mutation hash to be POSTed, got %+v", requests)
+	}
+}
+
+func TestExecuteAPQNotSupported(t *testing.T) {
+	var requests []apqRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := readAPQRequest(t, r)
+		requests = append(requests, request)
+
+		w.Header().Set("Content-Type", "application/json")
+		if request.Hash != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"errors": [{"message": "PersistedQueryNotSupported"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"test": "success"}}`)
+	}))
+	defer server.Close()
+
+	op := &Operation{
+		Name:    "TestQuery",
+		Query:   "query TestQuery { test }",
+		Project: &GraphQLProject{Options: ProjectOptions{APQ: APQOptions{Enabled: true}}},
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := Execute(context.Background(), server.URL, nil, op, nil); err != nil {
+			t.Fatalf("Execute returned an error: %v", err)
+		}
+	}
+
+	// Once the endpoint said it does not support APQ, only plain requests are sent
+	if len(requests) != 3 || requests[0].Hash == "" || requests[1].Hash != "" || requests[2].Hash != "" {
+		t.Errorf("Unexpected requests: %+v", requests)
+	}
+}
@@ -0,0 +1,253 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CircuitBreakerOptions control when calls to an unavailable endpoint fail fast, under `extensions.gqai.circuitBreaker`
+type CircuitBreakerOptions struct {
+	Disabled         bool          `yaml:"disabled"`         // Always send calls to the endpoint
+	FailureThreshold int           `yaml:"failureThreshold"` // Consecutive failed calls that open the circuit (default 5)
+	OpenTimeout      time.Duration `yaml:"openTimeout"`      // How long the circuit stays open before a trial call (default 30s)
+}
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+)
+
+// Circuit states
+const (
+	CircuitClosed   = "closed"    // Calls go through
+	CircuitOpen     = "open"      // Calls fail fast
+	CircuitHalfOpen = "half-open" // A trial call is let through to probe the endpoint
+)
+
+// CircuitState is a snapshot of the circuit breaker of a project's endpoint
+type CircuitState struct {
+	Project   string     `json:"project,omitempty"`
+	Endpoint  string     `json:"endpoint"`
+	State     string     `json:"state"`
+	Failures  int        `json:"failures"`            // Consecutive failed calls
+	LastError string     `json:"lastError,omitempty"` // Error of the last failed call
+	RetryAt   *time.Time `json:"retryAt,omitempty"`   // When an open circuit lets a trial call through
+}
+
+// CircuitOpenError is returned instead of calling an endpoint whose circuit is open
+type CircuitOpenError struct {
+	Endpoint string
+	Failures int
+	RetryAt  time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s is unavailable after %d consecutive failures, not calling it again until %s",
+		e.Endpoint, e.Failures, e.RetryAt.Format(time.RFC3339))
+}
+
+// circuitBreaker tracks the calls of one project to one endpoint
+type circuitBreaker struct {
+	mu        sync.Mutex
+	project   string
+	endpoint  string
+	options   CircuitBreakerOptions // Fixed when the breaker is created
+	state     string
+	failures  int
+	lastError string
+	retryAt   time.Time
+	trial     bool // a trial call is in flight while half-open
+}
+
+// breakerKey identifies a circuit breaker. Projects sharing an endpoint get a breaker each,
+// so that each is configured with its own options.
+type breakerKey struct {
+	project  string
+	endpoint string
+}
+
+var circuitBreakers = struct {
+	sync.Mutex
+	breakers map[breakerKey]*circuitBreaker
+}{breakers: map[breakerKey]*circuitBreaker{}}
+
+// endpointBreaker returns the circuit breaker of the project for the endpoint. Tool calls and introspection
+// of the endpoint share it, so that neither waits on an endpoint the other found unavailable.
+func endpointBreaker(project *GraphQLProject, endpoint string) *circuitBreaker {
+	var key breakerKey
+	var options CircuitBreakerOptions
+	if project != nil {
+		key.project = project.Name
+		options = project.Options.CircuitBreaker
+	}
+	if options.Disabled {
+		return nil
+	}
+	key.endpoint = endpoint
+
+	circuitBreakers.Lock()
+	defer circuitBreakers.Unlock()
+
+	breaker, ok := circuitBreakers.breakers[key]
+	if !ok {
+		breaker = &circuitBreaker{project: key.project, endpoint: endpoint, options: options, state: CircuitClosed}
+		circuitBreakers.breakers[key] = breaker
+	}
+	return breaker
+}
+
+// allow returns an error when the call must not be sent. Once the open timeout has passed,
+// a single trial call is let through; the others keep failing fast until it completes.
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Now().Before(b.retryAt) {
+			return &CircuitOpenError{Endpoint: b.endpoint, Failures: b.failures, RetryAt: b.retryAt}
+		}
+		b.state = CircuitHalfOpen
+		b.trial = true
+		return nil
+	case CircuitHalfOpen:
+		if b.trial {
+			return &CircuitOpenError{Endpoint: b.endpoint, Failures: b.failures, RetryAt: b.retryAt}
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a call let through by allow
+func (b *circuitBreaker) record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	trial := b.trial
+	b.trial = false
+
+	// Calls cancelled by the client say nothing about the endpoint, the next call is the trial instead
+	if errors.Is(err, context.Canceled) {
+		if trial {
+			b.state = CircuitOpen
+		}
+		return
+	}
+
+	if err == nil || !isEndpointFailure(err) {
+		b.state = CircuitClosed
+		b.failures = 0
+		b.lastError = ""
+		return
+	}
+
+	b.failures++
+	b.lastError = err.Error()
+	threshold := b.options.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultFailureThreshold
+	}
+	if trial || b.failures >= threshold {
+		b.state = CircuitOpen
+		b.retryAt = time.Now().Add(durationOr(b.options.OpenTimeout, defaultOpenTimeout))
+	}
+}
+
+// isEndpointFailure reports whether a call failed because the endpoint is unavailable:
+// it could not be reached, timed out or answered with a transient error status
+func isEndpointFailure(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError || retryableStatus[statusErr.StatusCode]
+	}
+	return errors.Is(err, context.DeadlineExceeded) || isNetworkError(err)
+}
+
+// CircuitStates returns the state of every circuit breaker used so far, sorted by project and endpoint
+func CircuitStates() []CircuitState {
+	circuitBreakers.Lock()
+	breakers := make([]*circuitBreaker, 0, len(circuitBreakers.breakers))
+	for _, breaker := range circuitBreakers.breakers {
+		breakers = append(breakers, breaker)
+	}
+	circuitBreakers.Unlock()
+
+	states := make([]CircuitState, 0, len(breakers))
+	for _, breaker := range breakers {
+		breaker.mu.Lock()
+		state := CircuitState{
+			Project:   breaker.project,
+			Endpoint:  breaker.endpoint,
+			State:     breaker.state,
+			Failures:  breaker.failures,
+			LastError: breaker.lastError,
+		}
+		if breaker.state == CircuitOpen {
+			retryAt := breaker.retryAt
+			state.RetryAt = &retryAt
+		}
+		breaker.mu.Unlock()
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Project != states[j].Project {
+			return states[i].Project < states[j].Project
+		}
+		return states[i].Endpoint < states[j].Endpoint
+	})
+	return states
+}
+
+// CheckEndpoint sends a minimal query to the project's endpoint, through its circuit breaker,
+// to find out whether it is available. The query is always POSTed in full: it is in no trusted documents
+// manifest, and the endpoint is not expected to have its persisted query hash.
+func CheckEndpoint(ctx context.Context, project *GraphQLProject) error {
+	endpoint, headers := project.Endpoint()
+	if endpoint == "" {
+		return fmt.Errorf("project %s has no endpoint", project.Name)
+	}
+
+	noRetry := false
+	op := &Operation{
+		Name:          "GqaiStatus",
+		Query:         "query GqaiStatus { __typename }",
+		OperationType: "query",
+		Metadata:      ToolMetadata{Retry: &noRetry},
+		Project:       project,
+	}
+	client, err := operationClient(op)
+	if err != nil {
+		return err
+	}
+
+	breaker := endpointBreaker(project, endpoint)
+	if err := breaker.allow(); err != nil {
+		return err
+	}
+	call := &call{client: client, endpoint: endpoint, headers: headers, op: op, progress: &progressSteps{ctx: ctx}}
+	_, err = call.send(ctx, http.MethodPost, graphqlRequest{Query: op.Query, OperationName: op.Name})
+	breaker.record(err)
+	return err
+}
+
+// CircuitStateOf returns the state of the circuit breaker of a project's endpoint
+func CircuitStateOf(project, endpoint string) CircuitState {
+	for _, state := range CircuitStates() {
+		if state.Project == project && state.Endpoint == endpoint {
+			return state
+		}
+	}
+	return CircuitState{Project: project, Endpoint: endpoint, State: CircuitClosed}
+}
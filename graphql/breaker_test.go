@@ -0,0 +1,207 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	// The endpoint is down until healthy is set
+	var requests int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data": {"test": "success"}}`)
+	}))
+	defer server.Close()
+
+	op := &Operation{
+		Name:          "TestQuery",
+		Query:         "query TestQuery { test }",
+		OperationType: "query",
+		Project: &GraphQLProject{Options: ProjectOptions{
+			Retry:          RetryOptions{MaxAttempts: 1},
+			CircuitBreaker: CircuitBreakerOptions{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond},
+		}},
+	}
+	execute := func() error {
+		_, err := Execute(context.Background(), server.URL, nil, op, nil)
+		return err
+	}
+
+	// The circuit opens after consecutive failures, and calls then fail fast
+	for i := 0; i < 2; i++ {
+		if err := execute(); err == nil {
+			t.Fatal("Expected an error from the endpoint, got nil")
+		}
+	}
+	var openErr *CircuitOpenError
+	if err := execute(); !errors.As(err, &openErr) || openErr.Failures != 2 {
+		t.Fatalf("Expected the circuit to be open, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected the open circuit not to call the endpoint, got %d requests", requests)
+	}
+	if state := CircuitStateOf("", server.URL); state.State != CircuitOpen || state.RetryAt == nil ||
+		state.LastError != "GraphQL error (503): " {
+		t.Errorf("Unexpected circuit state: %+v", state)
+	}
+
+	// After the open timeout, a failed trial call opens it again
+	time.Sleep(60 * time.Millisecond)
+	if err := execute(); err == nil || errors.As(err, &openErr) {
+		t.Fatalf("Expected the trial call to reach the endpoint, got %v", err)
+	}
+	if err := execute(); !errors.As(err, &openErr) {
+		t.Fatalf("Expected the circuit to be open again, got %v", err)
+	}
+
+	// A successful trial call closes it
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	if err := execute(); err != nil {
+		t.Fatalf("Expected the trial call to succeed, got %v", err)
+	}
+	if state := CircuitStateOf("", server.URL); state.State != CircuitClosed || state.Failures != 0 {
+		t.Errorf("Expected the circuit to be closed, got %+v", state)
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	op := &Operation{
+		Name:    "TestQuery",
+		Query:   "query TestQuery { test }",
+		Project: &GraphQLProject{Options: ProjectOptions{CircuitBreaker: CircuitBreakerOptions{FailureThreshold: 1}}},
+	}
+	for i := 0; i < 3; i++ {
+		_, err := Execute(context.Background(), server.URL, nil, op, nil)
+		var openErr *CircuitOpenError
+		if err == nil || errors.As(err, &openErr) {
+			t.Fatalf("Expected the endpoint's error, got %v", err)
+		}
+	}
+
+	// Cancelled calls do not count either
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	Execute(ctx, server.URL, nil, op, nil)
+
+	if state := CircuitStateOf("", server.URL); state.State != CircuitClosed || state.Failures != 0 {
+		t.Errorf("Expected the circuit to stay closed, got %+v", state)
+	}
+}
+
+func TestCircuitBreakerPerProject(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// Two projects share the endpoint, each with its own threshold
+	operation := func(project string, threshold int) *Operation {
+		return &Operation{
+			Name:  "TestQuery",
+			Query: "query TestQuery { test }",
+			Project: &GraphQLProject{Name: project, Options: ProjectOptions{
+				Retry:          RetryOptions{MaxAttempts: 1},
+				CircuitBreaker: CircuitBreakerOptions{FailureThreshold: threshold},
+			}},
+		}
+	}
+	strict, lenient := operation("strict", 1), operation("lenient", 3)
+
+	for i := 0; i < 2; i++ {
+		Execute(context.Background(), server.URL, nil, strict, nil)
+		Execute(context.Background(), server.URL, nil, lenient, nil)
+	}
+	if state := CircuitStateOf("strict", server.URL); state.State != CircuitOpen {
+		t.Errorf("Expected the strict project's circuit to be open, got %+v", state)
+	}
+	if state := CircuitStateOf("lenient", server.URL); state.State != CircuitClosed || state.Failures != 2 {
+		t.Errorf("Expected the lenient project's circuit to stay closed, got %+v", state)
+	}
+}
+
+func TestCircuitBreakerIntrospection(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	project := &GraphQLProject{
+		Name:   "introspection",
+		Schema: []SchemaPointer{{URL: server.URL}},
+		Options: ProjectOptions{
+			Retry:          RetryOptions{MaxAttempts: 1},
+			CircuitBreaker: CircuitBreakerOptions{FailureThreshold: 1, OpenTimeout: time.Minute},
+			Introspection:  IntrospectionOptions{CacheDir: t.TempDir()},
+		},
+	}
+
+	// A failed introspection opens the circuit of the endpoint
+	if _, err := IntrospectSDL(context.Background(), project, false); err == nil {
+		t.Fatal("Expected the introspection to fail")
+	}
+	if state := CircuitStateOf("introspection", server.URL); state.State != CircuitOpen {
+		t.Fatalf("Expected the circuit to be open, got %+v", state)
+	}
+
+	// While it is open, neither introspection nor tool calls reach the endpoint
+	var openErr *CircuitOpenError
+	if _, err := IntrospectSDL(context.Background(), project, false); !errors.As(err, &openErr) {
+		t.Errorf("Expected the introspection to fail fast, got %v", err)
+	}
+	op := &Operation{Name: "TestQuery", Query: "query TestQuery { test }", Project: project}
+	if _, err := Execute(context.Background(), server.URL, nil, op, nil); !errors.As(err, &openErr) {
+		t.Errorf("Expected the call to fail fast, got %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Expected a single request to the endpoint, got %d", n)
+	}
+}
+
+func TestCheckEndpoint(t *testing.T) {
+	var request graphqlRequest
+	var method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data": {"__typename": "Query"}}`)
+	}))
+	defer server.Close()
+
+	// The probe is sent in full, whatever the project sends its operations as
+	project := &GraphQLProject{Options: ProjectOptions{
+		Endpoint:         server.URL,
+		Method:           http.MethodGet,
+		APQ:              APQOptions{Enabled: true, GET: true},
+		TrustedDocuments: TrustedDocumentsOptions{Enabled: true},
+	}}
+	if err := CheckEndpoint(context.Background(), project); err != nil {
+		t.Fatalf("CheckEndpoint returned an error: %v", err)
+	}
+	if method != http.MethodPost || request.Query != "query GqaiStatus { __typename }" ||
+		request.DocumentID != "" || request.Extensions != nil {
+		t.Errorf("Expected a plain POST of the probe, got %s %+v", method, request)
+	}
+}
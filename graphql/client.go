@@ -0,0 +1,144 @@
+package graphql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// HTTPOptions configure the HTTP client used to reach a project's endpoint, under `extensions.gqai.http`
+type HTTPOptions struct {
+	Proxy                 string        `yaml:"proxy"`                 // Proxy URL, HTTP_PROXY, HTTPS_PROXY and NO_PROXY are used when unset
+	DialTimeout           time.Duration `yaml:"dialTimeout"`           // How long to wait for a connection (default 10s)
+	TLSHandshakeTimeout   time.Duration `yaml:"tlsHandshakeTimeout"`   // How long to wait for the TLS handshake (default 10s)
+	ResponseHeaderTimeout time.Duration `yaml:"responseHeaderTimeout"` // How long to wait for the response headers, unlimited when unset
+	IdleConnTimeout       time.Duration `yaml:"idleConnTimeout"`       // How long idle connections are kept open (default 90s)
+	MaxIdleConnsPerHost   int           `yaml:"maxIdleConnsPerHost"`   // Idle connections kept open per host (default 16)
+	CAFile                string        `yaml:"caFile"`                // PEM bundle of CAs trusted on top of the system ones
+	CertFile              string        `yaml:"certFile"`              // PEM client certificate for mutual TLS
+	KeyFile               string        `yaml:"keyFile"`               // PEM key of the client certificate
+	InsecureSkipVerify    bool          `yaml:"insecureSkipVerify"`    // Do not verify the server certificate, for local development only
+}
+
+const (
+	defaultDialTimeout         = 10 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultMaxIdleConnsPerHost = 16
+)
+
+// httpClients keeps one client per set of options, so that connections are pooled across tool calls
+var httpClients = struct {
+	sync.Mutex
+	clients map[HTTPOptions]*http.Client
+}{clients: map[HTTPOptions]*http.Client{}}
+
+// HTTPClient returns the shared client for the project's endpoint, configured from `extensions.gqai.http`
+func (p *GraphQLProject) HTTPClient() (*http.Client, error) {
+	return httpClient(p.Options.HTTP)
+}
+
+func httpClient(options HTTPOptions) (*http.Client, error) {
+	httpClients.Lock()
+	defer httpClients.Unlock()
+
+	if client, ok := httpClients.clients[options]; ok {
+		return client, nil
+	}
+
+	transport, err := newTransport(options)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport}
+	httpClients.clients[options] = client
+	return client, nil
+}
+
+// newTransport builds a pooling transport that negotiates HTTP/2 when the server supports it
+func newTransport(options HTTPOptions) (*http.Transport, error) {
+	tlsConfig, err := newTLSConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if options.Proxy != "" {
+		proxyURL, err := url.Parse(options.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid extensions.gqai.http.proxy: %v", err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   durationOr(options.DialTimeout, defaultDialTimeout),
+		KeepAlive: 30 * time.Second,
+	}
+	maxIdleConnsPerHost := options.MaxIdleConnsPerHost
+	if maxIdleConnsPerHost <= 0 {
+		maxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   durationOr(options.TLSHandshakeTimeout, defaultTLSHandshakeTimeout),
+		ResponseHeaderTimeout: options.ResponseHeaderTimeout,
+		IdleConnTimeout:       durationOr(options.IdleConnTimeout, defaultIdleConnTimeout),
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		ExpectContinueTimeout: time.Second,
+	}, nil
+}
+
+// newTLSConfig loads the CA bundle and client certificate of the options
+func newTLSConfig(options HTTPOptions) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: options.InsecureSkipVerify,
+	}
+
+	if options.CAFile != "" {
+		pem, err := os.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("failed to read CA bundle: no certificates found in %s", options.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if options.CertFile != "" || options.KeyFile != "" {
+		if options.CertFile == "" || options.KeyFile == "" {
+			return nil, fmt.Errorf("invalid extensions.gqai.http: certFile and keyFile must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+func durationOr(value, fallback time.Duration) time.Duration {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
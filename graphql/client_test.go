@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHTTPClientTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data": {"test": "success"}}`)
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatalf("Failed to write CA bundle: %v", err)
+	}
+
+	execute := func(options HTTPOptions) error {
+		op := &Operation{
+			Name:    "TestQuery",
+			Query:   "query TestQuery { test }",
+			Project: &GraphQLProject{Name: "test", Options: ProjectOptions{HTTP: options}},
+		}
+		_, err := Execute(context.Background(), server.URL, nil, op, nil)
+		return err
+	}
+
+	// The server certificate is not trusted by default
+	if err := execute(HTTPOptions{}); err == nil {
+		t.Error("Expected an error for an untrusted certificate, got nil")
+	}
+	if err := execute(HTTPOptions{CAFile: caFile}); err != nil {
+		t.Errorf("Expected the CA bundle to be trusted, got %v", err)
+	}
+	if err := execute(HTTPOptions{InsecureSkipVerify: true}); err != nil {
+		t.Errorf("Expected the certificate not to be verified, got %v", err)
+	}
+
+	err := execute(HTTPOptions{CertFile: caFile})
+	if err == nil || !strings.Contains(err.Error(), "certFile and keyFile must be set together") {
+		t.Errorf("Expected an error for a certificate without a key, got %v", err)
+	}
+	err = execute(HTTPOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	if err == nil || !strings.Contains(err.Error(), "failed to create HTTP client for project test") {
+		t.Errorf("Expected an error for a missing CA bundle, got %v", err)
+	}
+}
+
+func TestHTTPClientShared(t *testing.T) {
+	project := &GraphQLProject{Options: ProjectOptions{HTTP: HTTPOptions{MaxIdleConnsPerHost: 4}}}
+	client, err := project.HTTPClient()
+	if err != nil {
+		t.Fatalf("HTTPClient returned an error: %v", err)
+	}
+
+	// Projects with the same options share a client and its connections
+	other := &GraphQLProject{Options: ProjectOptions{HTTP: HTTPOptions{MaxIdleConnsPerHost: 4}}}
+	if otherClient, _ := other.HTTPClient(); otherClient != client {
+		t.Error("Expected projects with the same options to share a client")
+	}
+
+	transport := client.Transport.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != 4 || !transport.ForceAttemptHTTP2 {
+		t.Errorf("Unexpected transport settings: %+v", transport)
+	}
+}
+
+func TestHTTPClientProxy(t *testing.T) {
+	// The proxy receives requests for any host
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host != "graphql.internal" {
+			t.Errorf("Expected the proxy to receive the request for graphql.internal, got %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data": {"test": "proxied"}}`)
+	}))
+	defer proxy.Close()
+
+	op := &Operation{
+		Name:    "TestQuery",
+		Query:   "query TestQuery { test }",
+		Project: &GraphQLProject{Options: ProjectOptions{HTTP: HTTPOptions{Proxy: proxy.URL}}},
+	}
+	result, err := Execute(context.Background(), "http://graphql.internal/graphql", nil, op, nil)
+	if err != nil {
+		t.Fatalf("Execute returned an error: %v", err)
+	}
+	if data := result.(map[string]any)["data"].(map[string]any); data["test"] != "proxied" {
+		t.Errorf("Unexpected result: %v", result)
+	}
+}
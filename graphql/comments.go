@@ -0,0 +1,243 @@
+package graphql
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// DocComment is the `#` comment block written directly above an operation.
+// Free text is the description; lines starting with `@` are tags, e.g. `# @destructive true`,
+// and `# @arg <variable> <description>` lines describe the operation's variables.
+// A block description (`"""..."""`) above the operation takes precedence over the comment's text.
+type DocComment struct {
+	Description string
+	Tags        map[string]string
+	Args        map[string]string
+}
+
+// operationComment returns the doc comment of an operation defined in the given file
+func operationComment(file *DocumentFile, op *ast.OperationDefinition) DocComment {
+	if op.Position == nil {
+		return DocComment{}
+	}
+
+	line := op.Position.Line
+	description, start, hasDescription := leadingString(file.Raw, line)
+	if hasDescription {
+		line = start
+	}
+
+	comment := parseDocComment(leadingComment(file.Raw, line))
+	if hasDescription {
+		comment.Description = description
+	}
+	return comment
+}
+
+// operationDescription returns the description of an operation: its doc comment,
+// or else the schema descriptions of the root fields it selects
+func operationDescription(file *DocumentFile, op *ast.OperationDefinition, schema *ast.Schema) string {
+	if description := operationComment(file, op).Description; description != "" {
+		return description
+	}
+	if schema == nil {
+		return ""
+	}
+
+	var root *ast.Definition
+	switch op.Operation {
+	case ast.Query:
+		root = schema.Query
+	case ast.Mutation:
+		root = schema.Mutation
+	case ast.Subscription:
+		root = schema.Subscription
+	}
+	if root == nil {
+		return ""
+	}
+
+	var descriptions []string
+	for _, selection := range op.SelectionSet {
+		field, ok := selection.(*ast.Field)
+		if !ok {
+			continue
+		}
+		if def := root.Fields.ForName(field.Name); def != nil && def.Description != "" {
+			descriptions = append(descriptions, def.Description)
+		}
+	}
+	return strings.Join(descriptions, "\n\n")
+}
+
+// leadingComment returns the comment lines directly above the given (1-based) line of src,
+// without their `#` markers
+func leadingComment(src string, line int) []string {
+	lines := strings.Split(src, "\n")
+	var comment []string
+	for i := line - 2; i >= 0 && i < len(lines); i-- {
+		text := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(text, "#") {
+			break
+		}
+		comment = append([]string{strings.TrimSpace(strings.TrimPrefix(text, "#"))}, comment...)
+	}
+	return comment
+}
+
+// parseDocComment splits comment lines into the description and the `@tag value` lines.
+// A tag without a value is set to "true".
+func parseDocComment(lines []string) DocComment {
+	comment := DocComment{Tags: map[string]string{}, Args: map[string]string{}}
+	var description []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "@") {
+			description = append(description, line)
+			continue
+		}
+		key, value, _ := strings.Cut(strings.TrimPrefix(line, "@"), " ")
+		value = strings.TrimSpace(value)
+		if key == "arg" {
+			name, description, _ := strings.Cut(value, " ")
+			comment.Args[strings.TrimPrefix(name, "$")] = strings.TrimSpace(description)
+			continue
+		}
+		if value == "" {
+			value = "true"
+		}
+		comment.Tags[key] = value
+	}
+	comment.Description = strings.TrimSpace(strings.Join(description, "\n"))
+	return comment
+}
+
+// leadingString returns the value of the string written directly above the given (1-based) line of src,
+// such as a `"""` block description, and the line the string starts on
+func leadingString(src string, line int) (value string, start int, ok bool) {
+	lines := strings.Split(src, "\n")
+	end := line - 2
+	if end < 0 || end >= len(lines) {
+		return "", 0, false
+	}
+
+	last := strings.TrimSpace(lines[end])
+	if !strings.HasSuffix(last, `"`) {
+		return "", 0, false
+	}
+
+	if !strings.HasSuffix(last, `"""`) {
+		value, err := strconv.Unquote(last)
+		if err != nil {
+			return "", 0, false
+		}
+		return value, line - 1, true
+	}
+
+	for i := end; i >= 0; i-- {
+		text := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(text, `"""`) && (i < end || len(text) >= 6) {
+			block := strings.Join(lines[i:end+1], "\n")
+			block = strings.TrimSpace(block)
+			block = strings.TrimSuffix(strings.TrimPrefix(block, `"""`), `"""`)
+			return blockStringValue(block), i + 1, true
+		}
+	}
+	return "", 0, false
+}
+
+// blockStringValue removes the common indentation and surrounding blank lines of a block string,
+// as described in the GraphQL spec
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, `\"""`, `"""`), "\n")
+
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = strings.TrimLeft(lines[i], " \t")
+			}
+		}
+	}
+
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stripDescriptions blanks out the strings written between definitions, i.e. block descriptions,
+// which the parser does not accept in executable documents.
+// Newlines are kept so that positions in the stripped source match the original.
+func stripDescriptions(src string) string {
+	out := []byte(src)
+	depth := 0
+	for i := 0; i < len(src); {
+		switch src[i] {
+		case '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case '{', '(', '[':
+			depth++
+			i++
+		case '}', ')', ']':
+			depth--
+			i++
+		case '"':
+			end := stringEnd(src, i)
+			if depth == 0 {
+				for j := i; j < end; j++ {
+					if out[j] != '\n' {
+						out[j] = ' '
+					}
+				}
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	return string(out)
+}
+
+// stringEnd returns the offset just past the string starting at offset i of src
+func stringEnd(src string, i int) int {
+	if strings.HasPrefix(src[i:], `"""`) {
+		for j := i + 3; j < len(src); j++ {
+			if strings.HasPrefix(src[j:], `\"""`) {
+				j += 3
+			} else if strings.HasPrefix(src[j:], `"""`) {
+				return j + 3
+			}
+		}
+		return len(src)
+	}
+
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		case '\n':
+			return j
+		}
+	}
+	return len(src)
+}
@@ -5,25 +5,56 @@ import (
 	"net/textproto"
 	"os"
 	"regexp"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
+// DefaultProjectName is the name given to the project of a config file without a `projects` block
+const DefaultProjectName = "default"
+
 type SchemaPointer struct {
 	URL     string            `yaml:"-"` // URL will be set programmatically
 	Headers map[string]string `yaml:"headers,omitempty"`
 }
 
 type GraphQLProject struct {
+	Name       string          `yaml:"-"` // Name will be set programmatically
 	Schema     []SchemaPointer `yaml:"schema"`
 	Documents  []string        `yaml:"documents"`
 	Extensions map[string]any  `yaml:"extensions"`
 	Include    []string        `yaml:"include"`
 	Exclude    []string        `yaml:"exclude"`
+	Options    ProjectOptions  `yaml:"-"` // Options will be decoded from extensions.gqai
+}
+
+// ProjectOptions are the gqai specific settings of a project, configured under `extensions.gqai`
+type ProjectOptions struct {
+	Endpoint         string                    `yaml:"endpoint"` // Where operations are executed, when it differs from the schema source
+	Headers          map[string]string         `yaml:"headers"`  // Headers sent to the endpoint
+	Introspection    IntrospectionOptions      `yaml:"introspection"`
+	Validation       string                    `yaml:"validation"`       // How operations are validated against the schema: strict, warn or off
+	Timeout          time.Duration             `yaml:"timeout"`          // Timeout of tool calls, DefaultTimeout when unset
+	Scalars          map[string]map[string]any `yaml:"scalars"`          // JSON schema of custom scalars in tool schemas, by scalar name
+	HTTP             HTTPOptions               `yaml:"http"`             // HTTP client settings for the endpoint and introspection
+	Retry            RetryOptions              `yaml:"retry"`            // How calls failing with a transient error are retried
+	CircuitBreaker   CircuitBreakerOptions     `yaml:"circuitBreaker"`   // When calls to an unavailable endpoint fail fast
+	APQ              APQOptions                `yaml:"apq"`              // Automatic Persisted Queries
+	TrustedDocuments TrustedDocumentsOptions   `yaml:"trustedDocuments"` // Send document IDs instead of queries
+	Method           string                    `yaml:"method"`           // HTTP method of queries: POST (default) or GET; mutations are always POSTed
+}
+
+// IntrospectionOptions control how remote schemas are introspected and cached on disk
+type IntrospectionOptions struct {
+	Disabled bool          `yaml:"disabled"` // Never introspect remote schema pointers
+	TTL      time.Duration `yaml:"ttl"`      // How long a cached schema is used before revalidating it (default 1h)
+	CacheDir string        `yaml:"cacheDir"` // Where cached schemas are stored (default: the user cache dir)
 }
 
 type GraphQLProjects struct {
-	Projects map[string]GraphQLProject `yaml:"projects"`
+	Projects map[string]*GraphQLProject `yaml:"projects"`
 }
 
 type GraphQLConfig struct {
@@ -71,67 +102,214 @@ func expandEnvVars(s string) string {
 	})
 }
 
+// Projects returns every project in the config, sorted by name
+func (c *GraphQLConfig) Projects() []*GraphQLProject {
+	var projects []*GraphQLProject
+	if c.SingleProject != nil {
+		if c.SingleProject.Name == "" {
+			c.SingleProject.Name = DefaultProjectName
+		}
+		projects = append(projects, c.SingleProject)
+	}
+	if c.MultiProjects != nil {
+		names := make([]string, 0, len(c.MultiProjects.Projects))
+		for name := range c.MultiProjects.Projects {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			project := c.MultiProjects.Projects[name]
+			project.Name = name
+			projects = append(projects, project)
+		}
+	}
+	return projects
+}
+
+// Endpoint returns the URL operations are executed against and the headers to send with them.
+// It is `extensions.gqai.endpoint` when set, and the first remote schema pointer otherwise,
+// so a project can take its types from a local file and send requests elsewhere.
+func (p *GraphQLProject) Endpoint() (string, map[string]string) {
+	headers := make(map[string]string)
+
+	endpoint := p.Options.Endpoint
+	for _, pointer := range p.Schema {
+		if !pointer.IsRemote() || (endpoint != "" && pointer.URL != endpoint) {
+			continue
+		}
+		if endpoint == "" {
+			endpoint = pointer.URL
+		}
+		for key, value := range pointer.Headers {
+			headers[key] = value
+		}
+		break
+	}
+
+	for key, value := range p.Options.Headers {
+		headers[normalizeHeader(key)] = value
+	}
+	return endpoint, headers
+}
+
+// SelectProject returns a config narrowed down to the project with the given name
+func (c *GraphQLConfig) SelectProject(name string) (*GraphQLConfig, error) {
+	var names []string
+	for _, project := range c.Projects() {
+		if project.Name == name {
+			return &GraphQLConfig{SingleProject: project}, nil
+		}
+		names = append(names, project.Name)
+	}
+	return nil, fmt.Errorf("project %q not found (available: %v)", name, names)
+}
+
 func LoadGraphQLConfig(path string) (*GraphQLConfig, error) {
-	viper.SetConfigFile(path)
-	viper.SetConfigType("yaml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config: %v", err)
+	}
 
-	if err := viper.ReadInConfig(); err != nil {
+	// Decode into a generic map so that keys (URLs, project names) keep their case
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
 		return nil, fmt.Errorf("error reading config: %v", err)
 	}
 
-	// Create a new config object
-	config := &GraphQLConfig{
-		SingleProject: &GraphQLProject{},
+	// Case 1: a `projects` block with one or more named projects
+	if projects, ok := raw["projects"]; ok {
+		projectsMap, ok := projects.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("projects must be a map of project names to project configs")
+		}
+
+		config := &GraphQLConfig{
+			MultiProjects: &GraphQLProjects{Projects: make(map[string]*GraphQLProject)},
+		}
+		for name, value := range projectsMap {
+			projectMap, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("project %s must be a map", name)
+			}
+			project, err := parseProject(name, projectMap)
+			if err != nil {
+				return nil, fmt.Errorf("project %s: %w", name, err)
+			}
+			config.MultiProjects.Projects[name] = project
+		}
+		return config, nil
 	}
 
+	// Case 2: a single project at the top level
+	project, err := parseProject(DefaultProjectName, raw)
+	if err != nil {
+		return nil, err
+	}
+	return &GraphQLConfig{SingleProject: project}, nil
+}
+
+// parseProject builds a project from its raw config map
+func parseProject(name string, raw map[string]any) (*GraphQLProject, error) {
+	project := &GraphQLProject{Name: name}
+
 	// Parse schema configuration
-	if err := parseSchema(config); err != nil {
+	schema, err := parseSchema(raw["schema"])
+	if err != nil {
 		return nil, err
 	}
+	project.Schema = schema
 
 	// Parse documents configuration
-	if err := parseDocuments(config); err != nil {
+	if project.Documents, err = parseStringList("documents", raw["documents"]); err != nil {
 		return nil, err
 	}
 
 	// Parse include configuration
-	if err := parseInclude(config); err != nil {
+	if project.Include, err = parseStringList("include", raw["include"]); err != nil {
 		return nil, err
 	}
 
 	// Parse exclude configuration
-	if err := parseExclude(config); err != nil {
+	if project.Exclude, err = parseStringList("exclude", raw["exclude"]); err != nil {
 		return nil, err
 	}
 
-	return config, nil
+	// Parse extensions configuration
+	if extensions, ok := raw["extensions"]; ok && extensions != nil {
+		extensionsMap, ok := expandEnvVarsInValue(extensions).(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("extensions must be a map")
+		}
+		project.Extensions = extensionsMap
+
+		rawExtensions, _ := extensions.(map[string]any)
+		if err := parseOptions(rawExtensions["gqai"], &project.Options); err != nil {
+			return nil, err
+		}
+	}
+
+	return project, nil
+}
+
+// parseOptions expands the env vars of the `extensions.gqai` map and decodes it into typed project options
+func parseOptions(value any, options *ProjectOptions) error {
+	if value == nil {
+		return nil
+	}
+
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return fmt.Errorf("invalid extensions.gqai: %v", err)
+	}
+	expandOptionVars(&node)
+	data, err := yaml.Marshal(&node)
+	if err != nil {
+		return fmt.Errorf("invalid extensions.gqai: %v", err)
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(options); err != nil {
+		return fmt.Errorf("invalid extensions.gqai: %v", err)
+	}
+	if err := checkScalars(options.Scalars); err != nil {
+		return err
+	}
+	if err := checkTrustedDocuments(options.TrustedDocuments); err != nil {
+		return err
+	}
+	if options.Method != "" {
+		method, ok := parseMethod(options.Method)
+		if !ok {
+			return fmt.Errorf("invalid extensions.gqai.method %q: must be GET or POST", options.Method)
+		}
+		options.Method = method
+	}
+	return checkValidationMode(options.Validation)
 }
 
 // parseSchema handles the schema configuration which can be either a string or an array
-func parseSchema(config *GraphQLConfig) error {
-	schema := viper.Get("schema")
+func parseSchema(schema any) ([]SchemaPointer, error) {
 	if schema == nil {
-		return nil
+		return nil, nil
 	}
 
 	// Case 1: schema is a simple string URL
 	if urlStr, ok := schema.(string); ok {
-		config.SingleProject.Schema = append(config.SingleProject.Schema, SchemaPointer{
-			URL: expandEnvVars(urlStr),
-		})
-		return nil
+		return []SchemaPointer{{URL: expandEnvVars(urlStr)}}, nil
 	}
 
 	// Case 2: schema is an array
 	schemaArr, ok := schema.([]interface{})
 	if !ok {
-		return fmt.Errorf("schema must be a string URL or an array")
+		return nil, fmt.Errorf("schema must be a string URL or an array")
 	}
 
+	var pointers []SchemaPointer
 	for _, item := range schemaArr {
 		// Case 2.1: array item is a simple string URL
 		if urlStr, ok := item.(string); ok {
-			config.SingleProject.Schema = append(config.SingleProject.Schema, SchemaPointer{
+			pointers = append(pointers, SchemaPointer{
 				URL: expandEnvVars(urlStr),
 			})
 			continue
@@ -159,105 +337,103 @@ func parseSchema(config *GraphQLConfig) error {
 						}
 					}
 				}
-				// Expand env vars in other string fields in valueMap if needed
 			}
 
-			config.SingleProject.Schema = append(config.SingleProject.Schema, schemaPtr)
+			pointers = append(pointers, schemaPtr)
 			break // Only process the first URL in the map
 		}
 	}
 
-	return nil
+	return pointers, nil
 }
 
-// parseDocuments handles the documents configuration which can be either a string or an array
-func parseDocuments(config *GraphQLConfig) error {
-	documents := viper.Get("documents")
-	if documents == nil {
-		return nil
+// parseStringList handles config entries (documents, include, exclude) which can be either a string or an array
+func parseStringList(key string, value any) ([]string, error) {
+	if value == nil {
+		return nil, nil
 	}
 
-	// Case 1: documents is a simple string
-	if docStr, ok := documents.(string); ok {
-		config.SingleProject.Documents = append(config.SingleProject.Documents, expandEnvVars(docStr))
-		return nil
+	// Case 1: value is a simple string
+	if str, ok := value.(string); ok {
+		return []string{expandEnvVars(str)}, nil
 	}
 
-	// Case 2: documents is an array
-	docArr, ok := documents.([]interface{})
+	// Case 2: value is an array
+	arr, ok := value.([]interface{})
 	if !ok {
-		return fmt.Errorf("documents must be a string path or an array")
+		return nil, fmt.Errorf("%s must be a string path or an array", key)
 	}
 
-	for _, item := range docArr {
-		if docStr, ok := item.(string); ok {
-			config.SingleProject.Documents = append(config.SingleProject.Documents, expandEnvVars(docStr))
+	var result []string
+	for _, item := range arr {
+		if str, ok := item.(string); ok {
+			result = append(result, expandEnvVars(str))
 		}
 	}
 
-	return nil
+	return result, nil
 }
 
-// parseInclude handles the include configuration which can be either a string or an array
-func parseInclude(config *GraphQLConfig) error {
-	include := viper.Get("include")
-	if include == nil {
-		return nil
-	}
-
-	if config.SingleProject == nil {
-		config.SingleProject = &GraphQLProject{}
-	}
-
-	// Case 1: include is a simple string
-	if incStr, ok := include.(string); ok {
-		config.SingleProject.Include = append(config.SingleProject.Include, expandEnvVars(incStr))
-		return nil
-	}
-
-	// Case 2: include is an array
-	incArr, ok := include.([]interface{})
-	if !ok {
-		return fmt.Errorf("include must be a string path or an array")
-	}
+// expandOptionVars expands the env vars in the scalars of the gqai options. A value read from an env var drops
+// its string tag, so that `maxAttempts: ${ATTEMPTS}` decodes into a number or duration field; string fields
+// keep the expanded text as is. Strings written in the config, such as a quoted "null", keep their tag,
+// and so does an expanded value that YAML would read as null. The JSON schemas of custom scalars
+// are decoded into untyped values and keep their strings.
+func expandOptionVars(node *yaml.Node) {
+	expandNodeVars(node, true)
+}
 
-	for _, item := range incArr {
-		if incStr, ok := item.(string); ok {
-			config.SingleProject.Include = append(config.SingleProject.Include, expandEnvVars(incStr))
+func expandNodeVars(node *yaml.Node, retype bool) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" {
+			return
+		}
+		expanded := expandEnvVars(node.Value)
+		if retype && expanded != node.Value && !isNullScalar(expanded) {
+			node.Tag = ""
+			node.Style = 0
+		}
+		node.Value = expanded
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			expandNodeVars(node.Content[i+1], retype && node.Content[i].Value != "scalars")
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			expandNodeVars(item, retype)
 		}
 	}
-
-	return nil
 }
 
-// parseExclude handles the exclude configuration which can be either a string or an array
-func parseExclude(config *GraphQLConfig) error {
-	exclude := viper.Get("exclude")
-	if exclude == nil {
-		return nil
-	}
-
-	if config.SingleProject == nil {
-		config.SingleProject = &GraphQLProject{}
-	}
-
-	// Case 1: exclude is a simple string
-	if excStr, ok := exclude.(string); ok {
-		config.SingleProject.Exclude = append(config.SingleProject.Exclude, expandEnvVars(excStr))
-		return nil
-	}
-
-	// Case 2: exclude is an array
-	excArr, ok := exclude.([]interface{})
-	if !ok {
-		return fmt.Errorf("exclude must be a string path or an array")
+// isNullScalar reports whether YAML reads the plain scalar as null
+func isNullScalar(value string) bool {
+	switch value {
+	case "", "~", "null", "Null", "NULL":
+		return true
 	}
+	return false
+}
 
-	for _, item := range excArr {
-		if excStr, ok := item.(string); ok {
-			config.SingleProject.Exclude = append(config.SingleProject.Exclude, expandEnvVars(excStr))
+// expandEnvVarsInValue expands env vars in every string of a decoded YAML value.
+// Expanded values stay strings, so that tokens such as `0123` are sent as written.
+func expandEnvVarsInValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return expandEnvVars(v)
+	case map[string]any:
+		expanded := make(map[string]any, len(v))
+		for key, val := range v {
+			expanded[key] = expandEnvVarsInValue(val)
 		}
+		return expanded
+	case []any:
+		expanded := make([]any, len(v))
+		for i, val := range v {
+			expanded[i] = expandEnvVarsInValue(val)
+		}
+		return expanded
+	default:
+		return value
 	}
-
-	return nil
 }
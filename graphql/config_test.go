@@ -3,7 +3,9 @@ package graphql
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadGraphQLConfig(t *testing.T) {
@@ -389,3 +391,271 @@ exclude: ${TEST_EXCLUDE}
 		t.Fatalf("Expected header to be expanded, got %s", headers["X-Test-Header"])
 	}
 }
+
+func TestLoadGraphQLConfigWithProjects(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "graphqlconfig.yml")
+
+	configContent := `
+projects:
+  StarWars:
+    schema:
+      - https://swapi.example.com/graphql:
+          headers:
+            Authorization: Bearer sw-token
+    documents: starwars
+    include: starwars/**/*.graphql
+    extensions:
+      codegen:
+        name: swapi
+  github:
+    schema: https://api.github.com/graphql
+    documents:
+      - github/queries
+      - github/mutations
+    exclude: github/**/draft_*.graphql
+`
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create temporary config file: %v", err)
+	}
+
+	config, err := LoadGraphQLConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadGraphQLConfig returned an error: %v", err)
+	}
+
+	if config.SingleProject != nil {
+		t.Fatal("Expected SingleProject to be nil for a multi-project config")
+	}
+
+	projects := config.Projects()
+	if len(projects) != 2 {
+		t.Fatalf("Expected 2 projects, got %d", len(projects))
+	}
+
+	// Project names keep their case and are sorted
+	if projects[0].Name != "StarWars" || projects[1].Name != "github" {
+		t.Fatalf("Expected projects StarWars and github, got %s and %s", projects[0].Name, projects[1].Name)
+	}
+
+	starWars := projects[0]
+	if starWars.Schema[0].URL != "https://swapi.example.com/graphql" {
+		t.Fatalf("Expected StarWars schema URL, got %s", starWars.Schema[0].URL)
+	}
+	if starWars.Schema[0].Headers["Authorization"] != "Bearer sw-token" {
+		t.Fatalf("Expected StarWars Authorization header, got '%s'", starWars.Schema[0].Headers["Authorization"])
+	}
+	if len(starWars.Include) != 1 || starWars.Include[0] != "starwars/**/*.graphql" {
+		t.Fatalf("Expected StarWars include, got %v", starWars.Include)
+	}
+	codegen, ok := starWars.Extensions["codegen"].(map[string]any)
+	if !ok || codegen["name"] != "swapi" {
+		t.Fatalf("Expected StarWars extensions to be parsed, got %v", starWars.Extensions)
+	}
+
+	github := projects[1]
+	if len(github.Documents) != 2 {
+		t.Fatalf("Expected 2 github documents, got %v", github.Documents)
+	}
+	if len(github.Exclude) != 1 || github.Exclude[0] != "github/**/draft_*.graphql" {
+		t.Fatalf("Expected github exclude, got %v", github.Exclude)
+	}
+
+	// Narrow down to a single project
+	selected, err := config.SelectProject("github")
+	if err != nil {
+		t.Fatalf("SelectProject returned an error: %v", err)
+	}
+	if selected.SingleProject == nil || selected.SingleProject.Name != "github" {
+		t.Fatalf("Expected github to be selected, got %v", selected.SingleProject)
+	}
+
+	if _, err := config.SelectProject("missing"); err == nil {
+		t.Error("Expected error when selecting a missing project, got nil")
+	}
+}
+
+func TestLoadGraphQLConfigWithEndpoint(t *testing.T) {
+	os.Setenv("TEST_ENDPOINT_TOKEN", "endpoint-token")
+	t.Cleanup(func() { os.Unsetenv("TEST_ENDPOINT_TOKEN") })
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "graphqlconfig.yml")
+
+	configContent := `
+schema: ./schema.graphql
+documents: operations
+extensions:
+  gqai:
+    endpoint: https://api.example.com/graphql
+    headers:
+      authorization: Bearer ${TEST_ENDPOINT_TOKEN}
+    introspection:
+      ttl: 10m
+`
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create temporary config file: %v", err)
+	}
+
+	config, err := LoadGraphQLConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadGraphQLConfig returned an error: %v", err)
+	}
+
+	project := config.SingleProject
+	if project.Options.Introspection.TTL != 10*time.Minute {
+		t.Fatalf("Expected introspection TTL to be 10m, got %v", project.Options.Introspection.TTL)
+	}
+
+	endpoint, headers := project.Endpoint()
+	if endpoint != "https://api.example.com/graphql" {
+		t.Fatalf("Expected endpoint from extensions.gqai.endpoint, got %s", endpoint)
+	}
+	if headers["Authorization"] != "Bearer endpoint-token" {
+		t.Fatalf("Expected Authorization header to be expanded and normalized, got %v", headers)
+	}
+
+	// Without an endpoint, the first remote schema pointer is used
+	project = &GraphQLProject{
+		Schema: []SchemaPointer{
+			{URL: "./schema.graphql"},
+			{URL: "https://schema.example.com/graphql", Headers: map[string]string{"X-Key": "key"}},
+		},
+	}
+	endpoint, headers = project.Endpoint()
+	if endpoint != "https://schema.example.com/graphql" || headers["X-Key"] != "key" {
+		t.Fatalf("Expected the remote schema pointer to be the endpoint, got %s %v", endpoint, headers)
+	}
+
+	// Unknown gqai options are rejected
+	err = os.WriteFile(configPath, []byte("schema: ./schema.graphql\nextensions:\n  gqai:\n    endpiont: typo\n"), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create temporary config file: %v", err)
+	}
+	if _, err := LoadGraphQLConfig(configPath); err == nil {
+		t.Error("Expected error for an unknown extensions.gqai option, got nil")
+	}
+}
+
+func TestLoadGraphQLConfigWithEnvVarValues(t *testing.T) {
+	for name, value := range map[string]string{
+		"TEST_API_KEY":  "0123",
+		"TEST_TOKEN":    "123456789012345678901234567890",
+		"TEST_ATTEMPTS": "4",
+		"TEST_TIMEOUT":  "45s",
+		"TEST_NULL":     "null",
+	} {
+		t.Setenv(name, value)
+	}
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "graphqlconfig.yml")
+
+	configContent := `
+schema: ./schema.graphql
+extensions:
+  gqai:
+    endpoint: https://api.example.com/graphql
+    headers:
+      x-api-key: ${TEST_API_KEY}
+      x-token: $TEST_TOKEN
+      x-null: ${TEST_NULL}
+      x-quoted: "null"
+      x-tilde: '~'
+    timeout: ${TEST_TIMEOUT}
+    retry:
+      maxAttempts: ${TEST_ATTEMPTS}
+  other:
+    key: ${TEST_API_KEY}
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temporary config file: %v", err)
+	}
+
+	config, err := LoadGraphQLConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadGraphQLConfig returned an error: %v", err)
+	}
+
+	// Expanded values are kept as written, not read as numbers
+	project := config.SingleProject
+	_, headers := project.Endpoint()
+	if headers["X-Api-Key"] != "0123" || headers["X-Token"] != "123456789012345678901234567890" {
+		t.Errorf("Expected header values to be kept as written, got %v", headers)
+	}
+	for _, key := range []string{"X-Null", "X-Quoted"} {
+		if headers[key] != "null" {
+			t.Errorf("Expected header %s to be the string null, got %q", key, headers[key])
+		}
+	}
+	if headers["X-Tilde"] != "~" {
+		t.Errorf("Expected quoted ~ to be kept as written, got %q", headers["X-Tilde"])
+	}
+	if other, _ := project.Extensions["other"].(map[string]any); other["key"] != "0123" {
+		t.Errorf("Expected extension values to be kept as written, got %v", project.Extensions["other"])
+	}
+
+	// Typed options are parsed from the expanded text
+	if project.Options.Retry.MaxAttempts != 4 || project.Options.Timeout != 45*time.Second {
+		t.Errorf("Expected expanded typed options, got %+v", project.Options)
+	}
+}
+
+func TestLoadGraphQLConfigWithScalars(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "graphqlconfig.yml")
+
+	configContent := `
+schema: ./schema.graphql
+extensions:
+  gqai:
+    scalars:
+      Money:
+        type: string
+        pattern: ^[0-9]+\.[0-9]{2} [A-Z]{3}$
+        examples: ["12.50 USD"]
+      DateTime:
+        type: integer
+        description: Unix timestamp
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temporary config file: %v", err)
+	}
+
+	config, err := LoadGraphQLConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadGraphQLConfig returned an error: %v", err)
+	}
+
+	// Configured scalars are added to the built-in ones, and override them
+	scalars := config.SingleProject.Scalars()
+	if money := scalars["Money"]; money["type"] != "string" || money["pattern"] != `^[0-9]+\.[0-9]{2} [A-Z]{3}$` {
+		t.Errorf("Unexpected Money schema: %v", money)
+	}
+	if dateTime := scalars["DateTime"]; dateTime["type"] != "integer" || dateTime["format"] != nil {
+		t.Errorf("Expected the DateTime mapping to be overridden, got %v", dateTime)
+	}
+	if uuid := scalars["UUID"]; uuid["format"] != "uuid" {
+		t.Errorf("Expected the built-in UUID mapping, got %v", uuid)
+	}
+
+	// Unknown JSON schema types are rejected
+	configContent = "schema: ./schema.graphql\nextensions:\n  gqai:\n    scalars:\n      Money:\n        type: decimal\n"
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temporary config file: %v", err)
+	}
+	if _, err := LoadGraphQLConfig(configPath); err == nil || !strings.Contains(err.Error(), "scalars.Money") {
+		t.Errorf("Expected an error for an unknown scalar type, got %v", err)
+	}
+
+	// So are patterns that do not compile
+	configContent = "schema: ./schema.graphql\nextensions:\n  gqai:\n    scalars:\n      Money:\n        pattern: \"[0-9\"\n"
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temporary config file: %v", err)
+	}
+	if _, err := LoadGraphQLConfig(configPath); err == nil || !strings.Contains(err.Error(), "invalid pattern") {
+		t.Errorf("Expected an error for an invalid scalar pattern, got %v", err)
+	}
+}
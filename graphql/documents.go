@@ -0,0 +1,244 @@
+package graphql
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// documentExtensions are the file extensions picked up from directories and glob patterns
+var documentExtensions = map[string]bool{
+	".graphql":  true,
+	".gql":      true,
+	".graphqls": true,
+}
+
+// ResolveDocuments returns the sorted list of document files of a project.
+// Each `documents` entry can be a file, a directory (searched recursively) or a doublestar glob pattern.
+// The matched files are then filtered by the project's `include` and `exclude` globs.
+func ResolveDocuments(project *GraphQLProject) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+
+	addFile := func(path string) {
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, entry := range project.Documents {
+		// Case 1: entry is a glob pattern
+		if isGlobPattern(entry) {
+			matches, err := doublestar.FilepathGlob(entry, doublestar.WithFilesOnly())
+			if err != nil {
+				return nil, fmt.Errorf("invalid documents pattern %s: %v", entry, err)
+			}
+			for _, match := range matches {
+				if isDocumentFile(match) {
+					addFile(match)
+				}
+			}
+			continue
+		}
+
+		info, err := os.Stat(entry)
+		if err != nil {
+			return nil, fmt.Errorf("documents path %s: %v", entry, err)
+		}
+
+		// Case 2: entry is a single file, used regardless of its extension
+		if !info.IsDir() {
+			addFile(entry)
+			continue
+		}
+
+		// Case 3: entry is a directory
+		err = filepath.WalkDir(entry, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && isDocumentFile(path) {
+				addFile(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk documents directory %s: %v", entry, err)
+		}
+	}
+
+	var filtered []string
+	for _, file := range files {
+		if len(project.Include) > 0 && !matchesAnyPattern(project.Include, file) {
+			continue
+		}
+		if matchesAnyPattern(project.Exclude, file) {
+			continue
+		}
+		filtered = append(filtered, file)
+	}
+
+	sort.Strings(filtered)
+	return filtered, nil
+}
+
+func isGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[{")
+}
+
+func isDocumentFile(path string) bool {
+	return documentExtensions[filepath.Ext(path)]
+}
+
+// matchesAnyPattern reports whether the path matches one of the glob patterns.
+// Relative and absolute forms are both tried so that `./ops/*.graphql` matches `ops/a.graphql`
+// and absolute patterns match files found through relative document entries.
+func matchesAnyPattern(patterns []string, path string) bool {
+	candidates := []string{filepath.Clean(path)}
+	if abs, err := filepath.Abs(path); err == nil {
+		candidates = append(candidates, abs)
+	}
+
+	for _, pattern := range patterns {
+		pattern = filepath.Clean(pattern)
+		for _, candidate := range candidates {
+			if matched, _ := doublestar.PathMatch(pattern, candidate); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DocumentFile is a parsed document file
+type DocumentFile struct {
+	Path string
+	Raw  string
+	Doc  *ast.QueryDocument
+}
+
+// DocumentIndex holds every parsed document of a project and resolves fragment spreads across files
+type DocumentIndex struct {
+	Files     []*DocumentFile
+	Fragments map[string]*ast.FragmentDefinition
+	Errors    gqlerror.List // Missing and duplicate fragments, with file/line positions
+}
+
+// IndexDocuments parses the documents of a project and indexes their fragments by name.
+// Parse errors are returned directly; fragment problems are collected in the index's Errors.
+func IndexDocuments(project *GraphQLProject) (*DocumentIndex, error) {
+	files, err := ResolveDocuments(project)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &DocumentIndex{
+		Fragments: make(map[string]*ast.FragmentDefinition),
+	}
+
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		doc, parseErr := parser.ParseQuery(&ast.Source{
+			Name:  path,
+			Input: stripDescriptions(string(data)),
+		})
+		if parseErr != nil {
+			return nil, parseErr
+		}
+
+		for _, fragment := range doc.Fragments {
+			if existing, exists := index.Fragments[fragment.Name]; exists {
+				index.Errors = append(index.Errors, gqlerror.ErrorPosf(fragment.Position,
+					"Fragment %q is already defined at %s", fragment.Name, formatPosition(existing.Position)))
+				continue
+			}
+			index.Fragments[fragment.Name] = fragment
+		}
+
+		index.Files = append(index.Files, &DocumentFile{Path: path, Raw: string(data), Doc: doc})
+	}
+
+	// Report spreads of fragments that are not defined in any file
+	for _, file := range index.Files {
+		for _, op := range file.Doc.Operations {
+			index.Errors = append(index.Errors, index.missingFragments(op.SelectionSet)...)
+		}
+		for _, fragment := range file.Doc.Fragments {
+			index.Errors = append(index.Errors, index.missingFragments(fragment.SelectionSet)...)
+		}
+	}
+
+	return index, nil
+}
+
+// Resolve returns a document holding the operation and the transitive closure of fragments it spreads
+func (idx *DocumentIndex) Resolve(op *ast.OperationDefinition) *ast.QueryDocument {
+	return &ast.QueryDocument{
+		Operations: ast.OperationList{op},
+		Fragments:  idx.collectFragments(op.SelectionSet, map[string]bool{}, nil),
+	}
+}
+
+// collectFragments appends the transitive closure of fragments spread in the selection set
+func (idx *DocumentIndex) collectFragments(selectionSet ast.SelectionSet, seen map[string]bool, out ast.FragmentDefinitionList) ast.FragmentDefinitionList {
+	for _, selection := range selectionSet {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			out = idx.collectFragments(sel.SelectionSet, seen, out)
+		case *ast.InlineFragment:
+			out = idx.collectFragments(sel.SelectionSet, seen, out)
+		case *ast.FragmentSpread:
+			if seen[sel.Name] {
+				continue
+			}
+			seen[sel.Name] = true
+			fragment, ok := idx.Fragments[sel.Name]
+			if !ok {
+				continue
+			}
+			out = append(out, fragment)
+			out = idx.collectFragments(fragment.SelectionSet, seen, out)
+		}
+	}
+	return out
+}
+
+// missingFragments reports the fragment spreads of the selection set that have no definition
+func (idx *DocumentIndex) missingFragments(selectionSet ast.SelectionSet) gqlerror.List {
+	var errs gqlerror.List
+	for _, selection := range selectionSet {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			errs = append(errs, idx.missingFragments(sel.SelectionSet)...)
+		case *ast.InlineFragment:
+			errs = append(errs, idx.missingFragments(sel.SelectionSet)...)
+		case *ast.FragmentSpread:
+			if _, ok := idx.Fragments[sel.Name]; !ok {
+				errs = append(errs, gqlerror.ErrorPosf(sel.Position, "Unknown fragment %q", sel.Name))
+			}
+		}
+	}
+	return errs
+}
+
+// formatPosition formats a position as file:line:column
+func formatPosition(pos *ast.Position) string {
+	if pos == nil {
+		return "unknown position"
+	}
+	return fmt.Sprintf("%s:%d:%d", pos.Src.Name, pos.Line, pos.Column)
+}
@@ -0,0 +1,131 @@
+package graphql
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveDocuments(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Create a tree of document files
+	files := []string{
+		"ops/films/get_film.graphql",
+		"ops/films/draft_film.graphql",
+		"ops/people/get_person.gql",
+		"ops/readme.md",
+		"shared/fragments.gql",
+		"shared/types.graphqls",
+		"single/explicit.txt",
+	}
+	for _, file := range files {
+		path := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("query Q { q }"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	project := &GraphQLProject{
+		Documents: []string{
+			filepath.Join(tempDir, "ops/**/*.{graphql,gql}"),
+			filepath.Join(tempDir, "shared"),
+			filepath.Join(tempDir, "single/explicit.txt"),
+			// Overlapping entries are only returned once
+			filepath.Join(tempDir, "ops/films/get_film.graphql"),
+		},
+		Exclude: []string{filepath.Join(tempDir, "**/draft_*")},
+	}
+
+	resolved, err := ResolveDocuments(project)
+	if err != nil {
+		t.Fatalf("ResolveDocuments returned an error: %v", err)
+	}
+
+	expected := []string{
+		"ops/films/get_film.graphql",
+		"ops/people/get_person.gql",
+		"shared/fragments.gql",
+		"shared/types.graphqls",
+		"single/explicit.txt",
+	}
+	if len(resolved) != len(expected) {
+		t.Fatalf("Expected %d documents, got %d: %v", len(expected), len(resolved), resolved)
+	}
+	for i, file := range expected {
+		if resolved[i] != filepath.Join(tempDir, file) {
+			t.Fatalf("Expected document %d to be %s, got %s", i, file, resolved[i])
+		}
+	}
+
+	// Include narrows the matched set down
+	project.Include = []string{filepath.Join(tempDir, "ops/**")}
+	resolved, err = ResolveDocuments(project)
+	if err != nil {
+		t.Fatalf("ResolveDocuments returned an error: %v", err)
+	}
+	if len(resolved) != 2 {
+		t.Fatalf("Expected 2 included documents, got %v", resolved)
+	}
+
+	// A missing path is reported
+	project.Documents = []string{filepath.Join(tempDir, "missing")}
+	if _, err := ResolveDocuments(project); err == nil {
+		t.Error("Expected error for a missing documents path, got nil")
+	}
+}
+
+func TestIndexDocumentsFragmentErrors(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		"fragments/film.graphql": "fragment FilmFields on Film {\n  title\n}\n",
+		"fragments/dupe.graphql": "\nfragment FilmFields on Film {\n  director\n}\n",
+		"ops/get_film.graphql":   "query GetFilm {\n  film {\n    ...FilmFields\n    ...MissingFields\n  }\n}\n",
+	}
+	for file, content := range files {
+		path := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	index, err := IndexDocuments(&GraphQLProject{Documents: []string{tempDir}})
+	if err != nil {
+		t.Fatalf("IndexDocuments returned an error: %v", err)
+	}
+
+	if len(index.Errors) != 2 {
+		t.Fatalf("Expected 2 errors, got %d: %v", len(index.Errors), index.Errors)
+	}
+
+	// Files are indexed in sorted order, so the definition in film.graphql is the duplicate
+	duplicate := index.Errors[0]
+	if duplicate.Extensions["file"] != filepath.Join(tempDir, "fragments/film.graphql") || duplicate.Locations[0].Line != 1 {
+		t.Errorf("Expected duplicate fragment to be reported in film.graphql:1, got %v", duplicate)
+	}
+	if !strings.Contains(duplicate.Message, "dupe.graphql:2:1") {
+		t.Errorf("Expected duplicate fragment message to point to the first definition, got %s", duplicate.Message)
+	}
+
+	missing := index.Errors[1]
+	if missing.Extensions["file"] != filepath.Join(tempDir, "ops/get_film.graphql") || missing.Locations[0].Line != 4 {
+		t.Errorf("Expected missing fragment to be reported in get_film.graphql:4, got %v", missing)
+	}
+	if !strings.Contains(missing.Message, "MissingFields") {
+		t.Errorf("Expected missing fragment message to name the fragment, got %s", missing.Message)
+	}
+
+	// Loading operations refuses the broken documents
+	if _, err := LoadProjectOperations(context.Background(), &GraphQLProject{Documents: []string{tempDir}}); err == nil {
+		t.Error("Expected LoadProjectOperations to fail on fragment errors, got nil")
+	}
+}
@@ -2,54 +2,226 @@ package graphql
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"time"
 )
 
-func Execute(endpoint string, input map[string]any, op *Operation, headers map[string]string) (any, error) {
-	reqBody := graphqlRequest{
-		Query:     op.Raw,
-		Variables: input,
+// Execute sends the operation to the endpoint. The request is aborted when ctx is cancelled or times out.
+func Execute(ctx context.Context, endpoint string, input map[string]any, op *Operation, headers map[string]string) (any, error) {
+	request := graphqlRequest{
+		Query:         op.Document(),
+		OperationName: op.Name,
+		Variables:     input,
 	}
-	jsonBody, err := json.Marshal(reqBody)
+	var options ProjectOptions
+	if op.Project != nil {
+		options = op.Project.Options
+	}
+
+	client, err := operationClient(op)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(jsonBody))
+	breaker := endpointBreaker(op.Project, endpoint)
+	if err := breaker.allow(); err != nil {
+		return nil, err
+	}
+	call := &call{
+		client:   client,
+		endpoint: endpoint,
+		headers:  headers,
+		op:       op,
+		progress: &progressSteps{ctx: ctx},
+	}
+	var body []byte
+	switch {
+	case options.TrustedDocuments.Enabled:
+		body, err = call.send(ctx, op.Method(), options.TrustedDocuments.request(request))
+	case options.APQ.Enabled:
+		body, err = call.sendPersisted(ctx, request)
+	default:
+		body, err = call.send(ctx, op.Method(), request)
+	}
+	breaker.record(err)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
+	call.progress.report(0, "Done")
 
-	req.Header.Set("Content-Type", "application/json")
+	var result map[string]any
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse GraphQL response: %w", err)
+	}
+
+	return result, nil
+}
+
+// call holds what the requests made for a single execution of an operation share
+type call struct {
+	client   *http.Client
+	endpoint string
+	headers  map[string]string
+	op       *Operation
+	progress *progressSteps
+}
+
+// send sends the request, retrying it as the operation's retry policy allows, and returns the response body
+func (c *call) send(ctx context.Context, method string, request graphqlRequest) ([]byte, error) {
+	policy := c.op.retryPolicy()
+	started := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		c.progress.report(2, "Sending "+c.op.Name+" to "+c.endpoint)
+		resp, body, err := c.attempt(ctx, method, request)
+		if err == nil && resp.StatusCode != http.StatusOK && !isGraphQLResponse(resp, body) {
+			err = &statusError{StatusCode: resp.StatusCode, Body: string(body)}
+		}
+		if err == nil {
+			return body, nil
+		}
+
+		delay, retry := policy.retryDelay(ctx, attempt, started, resp, err)
+		if !retry {
+			if attempt > 1 {
+				return nil, fmt.Errorf("%w (after %d attempts)", err, attempt)
+			}
+			return nil, err
+		}
+		c.progress.report(3, fmt.Sprintf("Retrying %s in %s (attempt %d of %d): %v", c.op.Name, delay.Round(time.Millisecond), attempt+1, policy.maxAttempts, err))
+		if err := sleep(ctx, delay); err != nil {
+			return nil, fmt.Errorf("GraphQL request failed: %w", err)
+		}
+	}
+}
+
+// attempt makes a single attempt at the request, returning the response and its body
+func (c *call) attempt(ctx context.Context, method string, request graphqlRequest) (*http.Response, []byte, error) {
+	req, err := newHTTPRequest(ctx, method, c.endpoint, request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Accept", acceptHeader)
 
 	// Add any custom headers
-	for key, value := range headers {
+	for key, value := range c.headers {
 		req.Header.Set(key, value)
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("GraphQL request failed: %w", err)
+		return nil, nil, fmt.Errorf("GraphQL request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	c.progress.report(1, "Reading response")
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
 	}
+	return resp, body, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GraphQL error (%d): %s", resp.StatusCode, string(body))
+// newHTTPRequest encodes the GraphQL request as a JSON POST body, or as URL parameters for GET,
+// as described by the GraphQL over HTTP spec
+func newHTTPRequest(ctx context.Context, method, endpoint string, request graphqlRequest) (*http.Request, error) {
+	if method == http.MethodGet {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		params := u.Query()
+		if request.Query != "" {
+			params.Set("query", request.Query)
+		}
+		if request.DocumentID != "" {
+			params.Set("documentId", request.DocumentID)
+		}
+		if request.OperationName != "" {
+			params.Set("operationName", request.OperationName)
+		}
+		for name, value := range map[string]map[string]any{"variables": request.Variables, "extensions": request.Extensions} {
+			if len(value) == 0 {
+				continue
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal request: %w", err)
+			}
+			params.Set(name, string(encoded))
+		}
+		u.RawQuery = params.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		return req, nil
 	}
 
-	var result map[string]any
+	jsonBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// graphqlResponseMediaType is the media type of GraphQL over HTTP responses.
+// Unlike application/json, its status code tells apart request errors (4xx) from server errors (5xx),
+// and a request error still comes with a well-formed GraphQL response.
+const graphqlResponseMediaType = "application/graphql-response+json"
+
+// acceptHeader prefers GraphQL over HTTP responses, and accepts the older application/json
+const acceptHeader = graphqlResponseMediaType + ", application/json;q=0.9"
+
+// isGraphQLResponse reports whether a response with an error status is still a GraphQL response to return,
+// i.e. a GraphQL over HTTP response rejecting the request with GraphQL errors
+func isGraphQLResponse(resp *http.Response, body []byte) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != graphqlResponseMediaType {
+		return false
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 500 || retryableStatus[resp.StatusCode] {
+		return false
+	}
+
+	var result response
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse GraphQL response: %w", err)
+		return false
 	}
+	return result.Data != nil || len(result.Errors) > 0
+}
 
-	return result, nil
+// statusError is returned when the endpoint answers with a status other than 200 OK
+type statusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("GraphQL error (%d): %s", e.StatusCode, e.Body)
+}
+
+// operationClient returns the HTTP client of the operation's project, or the default one when it has no project
+func operationClient(op *Operation) (*http.Client, error) {
+	if op.Project == nil {
+		return httpClient(HTTPOptions{})
+	}
+	client, err := op.Project.HTTPClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client for project %s: %w", op.Project.Name, err)
+	}
+	return client, nil
 }
@@ -1,9 +1,13 @@
 package graphql
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -43,7 +47,7 @@ func TestExecuteWithHeaders(t *testing.T) {
 	}
 
 	// Execute the request with headers
-	result, err := Execute(server.URL, nil, op, headers)
+	result, err := Execute(context.Background(), server.URL, nil, op, headers)
 	if err != nil {
 		t.Fatalf("Execute returned an error: %v", err)
 	}
@@ -64,3 +68,130 @@ func TestExecuteWithHeaders(t *testing.T) {
 		t.Fatalf("Expected result.data.test to be 'success', got %v", data["test"])
 	}
 }
+
+// TestExecuteSendsOperationName tests that the minimal query and the operation name are sent
+func TestExecuteSendsOperationName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body graphqlRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode request body: %v", err)
+		}
+		if body.OperationName != "TestQuery" {
+			t.Errorf("Expected operationName to be 'TestQuery', got '%s'", body.OperationName)
+		}
+		if body.Query != "query TestQuery {\n\ttest\n}\n" {
+			t.Errorf("Expected the minimal query to be sent, got '%s'", body.Query)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data": {"test": "success"}}`)
+	}))
+	defer server.Close()
+
+	op := &Operation{
+		Name:          "TestQuery",
+		Raw:           "query TestQuery { test }\nquery OtherQuery { other }",
+		Query:         "query TestQuery {\n\ttest\n}\n",
+		OperationType: "query",
+	}
+
+	if _, err := Execute(context.Background(), server.URL, nil, op, nil); err != nil {
+		t.Fatalf("Execute returned an error: %v", err)
+	}
+}
+
+func TestExecuteGET(t *testing.T) {
+	var method string
+	var params url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		params = r.URL.Query()
+		if accept := r.Header.Get("Accept"); !strings.HasPrefix(accept, "application/graphql-response+json") {
+			t.Errorf("Expected GraphQL over HTTP responses to be accepted, got %q", accept)
+		}
+		w.Header().Set("Content-Type", "application/graphql-response+json")
+		fmt.Fprintf(w, `{"data": {"test": "success"}}`)
+	}))
+	defer server.Close()
+
+	project := &GraphQLProject{Options: ProjectOptions{Method: http.MethodGet}}
+	query := &Operation{
+		Name:          "TestQuery",
+		Query:         "query TestQuery($id: ID) { test(id: $id) }",
+		OperationType: "query",
+		Project:       project,
+	}
+	if _, err := Execute(context.Background(), server.URL+"?api-key=1", map[string]any{"id": "1"}, query, nil); err != nil {
+		t.Fatalf("Execute returned an error: %v", err)
+	}
+
+	// Queries are sent as URL parameters, keeping those of the endpoint
+	if method != http.MethodGet || params.Get("query") != query.Query || params.Get("operationName") != "TestQuery" ||
+		params.Get("variables") != `{"id":"1"}` || params.Get("api-key") != "1" {
+		t.Errorf("Unexpected request: %s %v", method, params)
+	}
+
+	// Mutations are never sent as GET
+	mutation := &Operation{
+		Name:          "TestMutation",
+		Query:         "mutation TestMutation { test }",
+		OperationType: "mutation",
+		Project:       project,
+	}
+	if _, err := Execute(context.Background(), server.URL, nil, mutation, nil); err != nil {
+		t.Fatalf("Execute returned an error: %v", err)
+	}
+	if method != http.MethodPost {
+		t.Errorf("Expected the mutation to be POSTed, got %s", method)
+	}
+
+	// The operation's method overrides the project's
+	query.Metadata.Method = http.MethodPost
+	if _, err := Execute(context.Background(), server.URL, nil, query, nil); err != nil {
+		t.Fatalf("Execute returned an error: %v", err)
+	}
+	if method != http.MethodPost {
+		t.Errorf("Expected the query to be POSTed, got %s", method)
+	}
+}
+
+func TestExecuteGraphQLResponseStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		status      int
+		wantErr     bool
+	}{
+		{name: "request error", contentType: "application/graphql-response+json; charset=utf-8", status: http.StatusBadRequest},
+		{name: "request error as JSON", contentType: "application/json", status: http.StatusBadRequest, wantErr: true},
+		{name: "server error", contentType: "application/graphql-response+json", status: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				fmt.Fprintf(w, `{"errors": [{"message": "Cannot query field \"test\""}]}`)
+			}))
+			defer server.Close()
+
+			op := &Operation{Name: "TestQuery", Query: "query TestQuery { test }"}
+			result, err := Execute(context.Background(), server.URL, nil, op, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got %v", result)
+				}
+				return
+			}
+
+			// A GraphQL over HTTP request error is a GraphQL response, its errors are returned as such
+			if err != nil {
+				t.Fatalf("Execute returned an error: %v", err)
+			}
+			if errors, hasData := ResponseErrors(result); len(errors) != 1 || hasData {
+				t.Errorf("Expected the GraphQL errors to be returned, got %v", result)
+			}
+		})
+	}
+}
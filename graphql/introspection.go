@@ -0,0 +1,387 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IntrospectionQuery is the standard query used to fetch the schema of a GraphQL endpoint
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      description
+      locations
+      args { ...InputValue }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType {
+          kind
+          name
+          ofType {
+            kind
+            name
+            ofType {
+              kind
+              name
+              ofType {
+                kind
+                name
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+`
+
+type introspectionSchema struct {
+	QueryType        *introspectionTypeRef     `json:"queryType"`
+	MutationType     *introspectionTypeRef     `json:"mutationType"`
+	SubscriptionType *introspectionTypeRef     `json:"subscriptionType"`
+	Types            []*introspectionType      `json:"types"`
+	Directives       []*introspectionDirective `json:"directives"`
+}
+
+type introspectionType struct {
+	Kind          string                     `json:"kind"`
+	Name          string                     `json:"name"`
+	Description   string                     `json:"description"`
+	Fields        []*introspectionField      `json:"fields"`
+	InputFields   []*introspectionInputValue `json:"inputFields"`
+	Interfaces    []*introspectionTypeRef    `json:"interfaces"`
+	EnumValues    []*introspectionEnumValue  `json:"enumValues"`
+	PossibleTypes []*introspectionTypeRef    `json:"possibleTypes"`
+}
+
+type introspectionField struct {
+	Name              string                     `json:"name"`
+	Description       string                     `json:"description"`
+	Args              []*introspectionInputValue `json:"args"`
+	Type              *introspectionTypeRef      `json:"type"`
+	IsDeprecated      bool                       `json:"isDeprecated"`
+	DeprecationReason *string                    `json:"deprecationReason"`
+}
+
+type introspectionInputValue struct {
+	Name         string                `json:"name"`
+	Description  string                `json:"description"`
+	Type         *introspectionTypeRef `json:"type"`
+	DefaultValue *string               `json:"defaultValue"`
+}
+
+type introspectionEnumValue struct {
+	Name              string  `json:"name"`
+	Description       string  `json:"description"`
+	IsDeprecated      bool    `json:"isDeprecated"`
+	DeprecationReason *string `json:"deprecationReason"`
+}
+
+type introspectionDirective struct {
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	Locations   []string                   `json:"locations"`
+	Args        []*introspectionInputValue `json:"args"`
+}
+
+type introspectionTypeRef struct {
+	Kind   string                `json:"kind"`
+	Name   string                `json:"name"`
+	OfType *introspectionTypeRef `json:"ofType"`
+}
+
+// builtinScalars and builtinDirectives are already defined by the GraphQL prelude
+var builtinScalars = map[string]bool{"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true}
+var builtinDirectives = map[string]bool{"skip": true, "include": true, "deprecated": true, "specifiedBy": true}
+
+// parseIntrospection decodes an introspection result, either a full response (`{"data": {"__schema": ...}}`)
+// or the bare `{"__schema": ...}` object
+func parseIntrospection(data []byte) (*introspectionSchema, error) {
+	var result struct {
+		Data *struct {
+			Schema *introspectionSchema `json:"__schema"`
+		} `json:"data"`
+		Schema *introspectionSchema `json:"__schema"`
+		Errors []graphqlError       `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse introspection result: %w", err)
+	}
+
+	if result.Data != nil && result.Data.Schema != nil {
+		return result.Data.Schema, nil
+	}
+	if result.Schema != nil {
+		return result.Schema, nil
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("introspection failed: %s", result.Errors[0].Message)
+	}
+	return nil, fmt.Errorf("introspection result has no __schema")
+}
+
+// mergeIntrospection adds the types of other to schema; types defined in both are merged field by field
+func mergeIntrospection(schema, other *introspectionSchema) {
+	types := make(map[string]*introspectionType, len(schema.Types))
+	for _, t := range schema.Types {
+		types[t.Name] = t
+	}
+
+	for _, t := range other.Types {
+		existing, ok := types[t.Name]
+		if !ok {
+			schema.Types = append(schema.Types, t)
+			types[t.Name] = t
+			continue
+		}
+		for _, field := range t.Fields {
+			if !hasNamed(existing.Fields, field.Name, func(f *introspectionField) string { return f.Name }) {
+				existing.Fields = append(existing.Fields, field)
+			}
+		}
+		for _, field := range t.InputFields {
+			if !hasNamed(existing.InputFields, field.Name, func(f *introspectionInputValue) string { return f.Name }) {
+				existing.InputFields = append(existing.InputFields, field)
+			}
+		}
+		for _, value := range t.EnumValues {
+			if !hasNamed(existing.EnumValues, value.Name, func(v *introspectionEnumValue) string { return v.Name }) {
+				existing.EnumValues = append(existing.EnumValues, value)
+			}
+		}
+		for _, ref := range t.PossibleTypes {
+			if !hasNamed(existing.PossibleTypes, ref.Name, func(r *introspectionTypeRef) string { return r.Name }) {
+				existing.PossibleTypes = append(existing.PossibleTypes, ref)
+			}
+		}
+	}
+
+	if schema.MutationType == nil {
+		schema.MutationType = other.MutationType
+	}
+	if schema.SubscriptionType == nil {
+		schema.SubscriptionType = other.SubscriptionType
+	}
+	for _, directive := range other.Directives {
+		if !hasNamed(schema.Directives, directive.Name, func(d *introspectionDirective) string { return d.Name }) {
+			schema.Directives = append(schema.Directives, directive)
+		}
+	}
+}
+
+func hasNamed[T any](items []T, name string, nameOf func(T) string) bool {
+	for _, item := range items {
+		if nameOf(item) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// toSDL prints the introspected schema as GraphQL SDL
+func (s *introspectionSchema) toSDL() string {
+	var buf bytes.Buffer
+
+	buf.WriteString("schema {\n")
+	if s.QueryType != nil {
+		fmt.Fprintf(&buf, "  query: %s\n", s.QueryType.Name)
+	}
+	if s.MutationType != nil {
+		fmt.Fprintf(&buf, "  mutation: %s\n", s.MutationType.Name)
+	}
+	if s.SubscriptionType != nil {
+		fmt.Fprintf(&buf, "  subscription: %s\n", s.SubscriptionType.Name)
+	}
+	buf.WriteString("}\n")
+
+	directives := append([]*introspectionDirective{}, s.Directives...)
+	sort.Slice(directives, func(i, j int) bool { return directives[i].Name < directives[j].Name })
+	for _, directive := range directives {
+		if builtinDirectives[directive.Name] {
+			continue
+		}
+		buf.WriteString("\n")
+		writeDescription(&buf, "", directive.Description)
+		fmt.Fprintf(&buf, "directive @%s%s on %s\n", directive.Name, formatArgs(directive.Args), strings.Join(directive.Locations, " | "))
+	}
+
+	types := append([]*introspectionType{}, s.Types...)
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	for _, t := range types {
+		if strings.HasPrefix(t.Name, "__") || (t.Kind == "SCALAR" && builtinScalars[t.Name]) {
+			continue
+		}
+
+		buf.WriteString("\n")
+		writeDescription(&buf, "", t.Description)
+
+		switch t.Kind {
+		case "SCALAR":
+			fmt.Fprintf(&buf, "scalar %s\n", t.Name)
+
+		case "OBJECT", "INTERFACE":
+			keyword := "type"
+			if t.Kind == "INTERFACE" {
+				keyword = "interface"
+			}
+			fmt.Fprintf(&buf, "%s %s%s {\n", keyword, t.Name, formatImplements(t.Interfaces))
+			for _, field := range t.Fields {
+				writeDescription(&buf, "  ", field.Description)
+				fmt.Fprintf(&buf, "  %s%s: %s%s\n", field.Name, formatArgs(field.Args), field.Type.String(), formatDeprecated(field.IsDeprecated, field.DeprecationReason))
+			}
+			buf.WriteString("}\n")
+
+		case "UNION":
+			var members []string
+			for _, ref := range t.PossibleTypes {
+				members = append(members, ref.Name)
+			}
+			fmt.Fprintf(&buf, "union %s = %s\n", t.Name, strings.Join(members, " | "))
+
+		case "ENUM":
+			fmt.Fprintf(&buf, "enum %s {\n", t.Name)
+			for _, value := range t.EnumValues {
+				writeDescription(&buf, "  ", value.Description)
+				fmt.Fprintf(&buf, "  %s%s\n", value.Name, formatDeprecated(value.IsDeprecated, value.DeprecationReason))
+			}
+			buf.WriteString("}\n")
+
+		case "INPUT_OBJECT":
+			fmt.Fprintf(&buf, "input %s {\n", t.Name)
+			for _, field := range t.InputFields {
+				writeDescription(&buf, "  ", field.Description)
+				fmt.Fprintf(&buf, "  %s\n", formatInputValue(field))
+			}
+			buf.WriteString("}\n")
+		}
+	}
+
+	return buf.String()
+}
+
+// String formats the type reference as a GraphQL type, e.g. `[String!]!`
+func (t *introspectionTypeRef) String() string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	default:
+		return t.Name
+	}
+}
+
+func writeDescription(buf *bytes.Buffer, indent string, description string) {
+	if description == "" {
+		return
+	}
+	escaped := strings.ReplaceAll(description, `"""`, `\"""`)
+	fmt.Fprintf(buf, "%s\"\"\"\n", indent)
+	for _, line := range strings.Split(escaped, "\n") {
+		fmt.Fprintf(buf, "%s%s\n", indent, line)
+	}
+	fmt.Fprintf(buf, "%s\"\"\"\n", indent)
+}
+
+func formatImplements(interfaces []*introspectionTypeRef) string {
+	if len(interfaces) == 0 {
+		return ""
+	}
+	var names []string
+	for _, ref := range interfaces {
+		names = append(names, ref.Name)
+	}
+	return " implements " + strings.Join(names, " & ")
+}
+
+// formatArgs formats field and directive arguments, with their descriptions as inline strings
+func formatArgs(args []*introspectionInputValue) string {
+	if len(args) == 0 {
+		return ""
+	}
+	var formatted []string
+	for _, arg := range args {
+		value := formatInputValue(arg)
+		if arg.Description != "" {
+			quoted, _ := json.Marshal(arg.Description)
+			value = string(quoted) + " " + value
+		}
+		formatted = append(formatted, value)
+	}
+	return "(" + strings.Join(formatted, ", ") + ")"
+}
+
+func formatInputValue(value *introspectionInputValue) string {
+	result := value.Name + ": " + value.Type.String()
+	if value.DefaultValue != nil {
+		result += " = " + *value.DefaultValue
+	}
+	return result
+}
+
+func formatDeprecated(isDeprecated bool, reason *string) string {
+	if !isDeprecated {
+		return ""
+	}
+	if reason == nil || *reason == "" {
+		return " @deprecated"
+	}
+	quoted, _ := json.Marshal(*reason)
+	return fmt.Sprintf(" @deprecated(reason: %s)", quoted)
+}
@@ -0,0 +1,342 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// testIntrospection is a small introspection result covering every kind of type
+const testIntrospection = `{"data": {"__schema": {
+  "queryType": {"name": "Query"},
+  "mutationType": {"name": "Mutation"},
+  "subscriptionType": null,
+  "types": [
+    {"kind": "OBJECT", "name": "Query", "description": "The root query", "fields": [
+      {"name": "film", "description": "Get a film by ID", "args": [
+        {"name": "id", "description": "ID of the film", "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID", "ofType": null}}, "defaultValue": null}
+      ], "type": {"kind": "OBJECT", "name": "Film", "ofType": null}, "isDeprecated": false, "deprecationReason": null},
+      {"name": "search", "description": null, "args": [], "type": {"kind": "LIST", "name": null, "ofType": {"kind": "UNION", "name": "SearchResult", "ofType": null}}, "isDeprecated": false, "deprecationReason": null}
+    ], "inputFields": null, "interfaces": [], "enumValues": null, "possibleTypes": null},
+    {"kind": "OBJECT", "name": "Mutation", "description": null, "fields": [
+      {"name": "addFilm", "description": null, "args": [
+        {"name": "film", "description": null, "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "INPUT_OBJECT", "name": "FilmInput", "ofType": null}}, "defaultValue": null}
+      ], "type": {"kind": "OBJECT", "name": "Film", "ofType": null}, "isDeprecated": false, "deprecationReason": null}
+    ], "inputFields": null, "interfaces": [], "enumValues": null, "possibleTypes": null},
+    {"kind": "INTERFACE", "name": "Node", "description": null, "fields": [
+      {"name": "id", "description": null, "args": [], "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID", "ofType": null}}, "isDeprecated": false, "deprecationReason": null}
+    ], "inputFields": null, "interfaces": [], "enumValues": null, "possibleTypes": [{"kind": "OBJECT", "name": "Film", "ofType": null}]},
+    {"kind": "OBJECT", "name": "Film", "description": "A \"Star Wars\" film", "fields": [
+      {"name": "id", "description": null, "args": [], "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID", "ofType": null}}, "isDeprecated": false, "deprecationReason": null},
+      {"name": "title", "description": null, "args": [], "type": {"kind": "SCALAR", "name": "String", "ofType": null}, "isDeprecated": false, "deprecationReason": null},
+      {"name": "released", "description": null, "args": [], "type": {"kind": "SCALAR", "name": "Date", "ofType": null}, "isDeprecated": false, "deprecationReason": null},
+      {"name": "episode", "description": null, "args": [], "type": {"kind": "ENUM", "name": "Episode", "ofType": null}, "isDeprecated": true, "deprecationReason": "Use title"}
+    ], "inputFields": null, "interfaces": [{"kind": "INTERFACE", "name": "Node", "ofType": null}], "enumValues": null, "possibleTypes": null},
+    {"kind": "OBJECT", "name": "Person", "description": null, "fields": [
+      {"name": "name", "description": null, "args": [], "type": {"kind": "SCALAR", "name": "String", "ofType": null}, "isDeprecated": false, "deprecationReason": null}
+    ], "inputFields": null, "interfaces": [], "enumValues": null, "possibleTypes": null},
+    {"kind": "UNION", "name": "SearchResult", "description": null, "fields": null, "inputFields": null, "interfaces": null, "enumValues": null, "possibleTypes": [
+      {"kind": "OBJECT", "name": "Film", "ofType": null}, {"kind": "OBJECT", "name": "Person", "ofType": null}
+    ]},
+    {"kind": "ENUM", "name": "Episode", "description": null, "fields": null, "inputFields": null, "interfaces": null, "enumValues": [
+      {"name": "NEWHOPE", "description": "Released in 1977", "isDeprecated": false, "deprecationReason": null},
+      {"name": "EMPIRE", "description": null, "isDeprecated": false, "deprecationReason": null}
+    ], "possibleTypes": null},
+    {"kind": "INPUT_OBJECT", "name": "FilmInput", "description": null, "fields": null, "inputFields": [
+      {"name": "title", "description": "The title", "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "String", "ofType": null}}, "defaultValue": null},
+      {"name": "episode", "description": null, "type": {"kind": "ENUM", "name": "Episode", "ofType": null}, "defaultValue": "NEWHOPE"}
+    ], "interfaces": null, "enumValues": null, "possibleTypes": null},
+    {"kind": "SCALAR", "name": "Date", "description": "An ISO-8601 date", "fields": null, "inputFields": null, "interfaces": null, "enumValues": null, "possibleTypes": null},
+    {"kind": "SCALAR", "name": "String", "description": "Built-in", "fields": null, "inputFields": null, "interfaces": null, "enumValues": null, "possibleTypes": null},
+    {"kind": "SCALAR", "name": "ID", "description": "Built-in", "fields": null, "inputFields": null, "interfaces": null, "enumValues": null, "possibleTypes": null},
+    {"kind": "OBJECT", "name": "__Schema", "description": null, "fields": [], "inputFields": null, "interfaces": [], "enumValues": null, "possibleTypes": null}
+  ],
+  "directives": [
+    {"name": "skip", "description": null, "locations": ["FIELD"], "args": [
+      {"name": "if", "description": null, "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "Boolean", "ofType": null}}, "defaultValue": null}
+    ]},
+    {"name": "cacheControl", "description": "Cache hints", "locations": ["FIELD_DEFINITION", "OBJECT"], "args": [
+      {"name": "maxAge", "description": null, "type": {"kind": "SCALAR", "name": "Int", "ofType": null}, "defaultValue": null}
+    ]}
+  ]
+}}}`
+
+func TestIntrospectionToSDL(t *testing.T) {
+	introspection, err := parseIntrospection([]byte(testIntrospection))
+	if err != nil {
+		t.Fatalf("parseIntrospection returned an error: %v", err)
+	}
+
+	sdl := introspection.toSDL()
+	for _, want := range []string{
+		"schema {\n  query: Query\n  mutation: Mutation\n}",
+		`film("ID of the film" id: ID!): Film`,
+		"search: [SearchResult]",
+		"type Film implements Node {",
+		`episode: Episode @deprecated(reason: "Use title")`,
+		"union SearchResult = Film | Person",
+		"episode: Episode = NEWHOPE",
+		"scalar Date",
+		"directive @cacheControl(maxAge: Int) on FIELD_DEFINITION | OBJECT",
+		"\"\"\"\nA \"Star Wars\" film\n\"\"\"\ntype Film",
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("Expected SDL to contain %q, got:\n%s", want, sdl)
+		}
+	}
+	for _, unwanted := range []string{"__Schema", "scalar String", "directive @skip"} {
+		if strings.Contains(sdl, unwanted) {
+			t.Errorf("Expected SDL not to contain %q, got:\n%s", unwanted, sdl)
+		}
+	}
+
+	// The SDL loads as a typed schema
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: sdl})
+	if gqlErr != nil {
+		t.Fatalf("Failed to load SDL: %v\n%s", gqlErr, sdl)
+	}
+	if schema.Types["FilmInput"].Fields.ForName("title").Description != "The title" {
+		t.Error("Expected input field descriptions to be kept")
+	}
+	if schema.Query.Fields.ForName("film").Arguments.ForName("id").Description != "ID of the film" {
+		t.Error("Expected argument descriptions to be kept")
+	}
+}
+
+func TestIntrospectionCache(t *testing.T) {
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Expected introspection to send the schema headers, got '%s'", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, testIntrospection)
+	}))
+	defer server.Close()
+
+	pointer := SchemaPointer{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}}
+	options := IntrospectionOptions{CacheDir: t.TempDir(), TTL: time.Hour}
+
+	// The first call fetches and caches the schema
+	if _, err := introspectPointer(context.Background(), http.DefaultClient, nil, pointer, options, false); err != nil {
+		t.Fatalf("introspectPointer returned an error: %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("Expected 1 request, got %d", requests)
+	}
+
+	// Within the TTL the cached schema is used
+	if _, err := introspectPointer(context.Background(), http.DefaultClient, nil, pointer, options, false); err != nil {
+		t.Fatalf("introspectPointer returned an error: %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("Expected the cached schema to be used, got %d requests", requests)
+	}
+
+	// After the TTL the cached schema is revalidated with its ETag
+	options.TTL = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	schema, err := introspectPointer(context.Background(), http.DefaultClient, nil, pointer, options, false)
+	if err != nil {
+		t.Fatalf("introspectPointer returned an error: %v", err)
+	}
+	if requests != 2 || notModified != 1 {
+		t.Fatalf("Expected a conditional request answered with 304, got %d requests and %d not modified", requests, notModified)
+	}
+	if schema.QueryType.Name != "Query" {
+		t.Fatalf("Expected the cached schema to be returned on 304, got %v", schema.QueryType)
+	}
+
+	// When the endpoint is down, the stale cache is used
+	server.Close()
+	if _, err := introspectPointer(context.Background(), http.DefaultClient, nil, pointer, options, false); err != nil {
+		t.Fatalf("Expected stale cache to be used when the endpoint is down, got %v", err)
+	}
+}
+
+func TestLoadSchemaFromIntrospection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, testIntrospection)
+	}))
+	defer server.Close()
+
+	project := &GraphQLProject{
+		Schema:  []SchemaPointer{{URL: server.URL}},
+		Options: ProjectOptions{Introspection: IntrospectionOptions{CacheDir: t.TempDir()}},
+	}
+
+	schema, err := LoadSchema(context.Background(), project)
+	if err != nil {
+		t.Fatalf("LoadSchema returned an error: %v", err)
+	}
+	if schema == nil || schema.Query.Fields.ForName("film") == nil {
+		t.Fatalf("Expected the introspected schema to be loaded, got %v", schema)
+	}
+
+	// Disabled introspection never calls the endpoint
+	project.Options.Introspection.Disabled = true
+	if schema, _ := LoadSchema(context.Background(), project); schema != nil {
+		t.Error("Expected no schema when introspection is disabled")
+	}
+}
+
+func TestLoadSchemaFromFiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// An introspection result and an SDL file extending it
+	jsonPath := filepath.Join(tempDir, "schema.json")
+	if err := os.WriteFile(jsonPath, []byte(testIntrospection), 0644); err != nil {
+		t.Fatalf("Failed to create schema file: %v", err)
+	}
+	sdlPath := filepath.Join(tempDir, "extra.graphql")
+	if err := os.WriteFile(sdlPath, []byte("extend type Query { planet: String }"), 0644); err != nil {
+		t.Fatalf("Failed to create schema file: %v", err)
+	}
+
+	project := &GraphQLProject{
+		Schema: []SchemaPointer{{URL: jsonPath}, {URL: sdlPath}},
+	}
+
+	schema, err := LoadSchema(context.Background(), project)
+	if err != nil {
+		t.Fatalf("LoadSchema returned an error: %v", err)
+	}
+	if schema.Query.Fields.ForName("film") == nil || schema.Query.Fields.ForName("planet") == nil {
+		t.Fatal("Expected fields from both schema files")
+	}
+}
+
+func TestLoadRemoteSchemaConcurrently(t *testing.T) {
+	release := make(chan struct{})
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, testIntrospection)
+	}))
+	defer server.Close()
+
+	project := &GraphQLProject{
+		Schema:  []SchemaPointer{{URL: server.URL}},
+		Options: ProjectOptions{Introspection: IntrospectionOptions{CacheDir: t.TempDir()}},
+	}
+
+	// A caller stops waiting when its context is done, while the introspection goes on
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := LoadSchema(ctx, project); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the wait to time out, got %v", err)
+	}
+
+	// Other calls share the introspection in flight
+	var wg sync.WaitGroup
+	schemas := make([]*ast.Schema, 5)
+	for i := range schemas {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			schemas[i], _ = LoadSchema(context.Background(), project)
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for _, schema := range schemas {
+		if schema == nil || schema.Query.Fields.ForName("film") == nil {
+			t.Fatalf("Expected the introspected schema, got %v", schema)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Expected a single introspection request, got %d", n)
+	}
+}
+
+func TestLoadRemoteSchemaWithoutWaiting(t *testing.T) {
+	// Each introspection request hangs until it is allowed through
+	allow := make(chan struct{}, 1)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-allow
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, testIntrospection)
+	}))
+	defer server.Close()
+	defer close(allow)
+
+	cacheDir := t.TempDir()
+	project := &GraphQLProject{
+		Schema: []SchemaPointer{{URL: server.URL}},
+		Options: ProjectOptions{
+			Timeout:       50 * time.Millisecond,
+			Introspection: IntrospectionOptions{CacheDir: cacheDir},
+		},
+	}
+
+	// The first load waits for the project's timeout at most, then goes on without a schema
+	start := time.Now()
+	schema, err := LoadSchema(context.Background(), project)
+	if err != nil || schema != nil {
+		t.Fatalf("Expected no schema while the introspection hangs, got %v, %v", schema, err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Expected the wait to end after the project's timeout, took %s", elapsed)
+	}
+
+	// The introspection completes in the background
+	allow <- struct{}{}
+	deadline := time.Now().Add(5 * time.Second)
+	for schema == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		schema, _ = LoadSchema(context.Background(), project)
+	}
+	if schema == nil {
+		t.Fatal("Expected the schema to be loaded in the background")
+	}
+
+	// Once expired, the schema is still returned right away while it is refreshed from the endpoint
+	if err := os.RemoveAll(cacheDir); err != nil {
+		t.Fatalf("Failed to remove the schema cache: %v", err)
+	}
+	key := cacheKey(project.Schema)
+	remoteSchemas.Lock()
+	entry := remoteSchemas.entries[key]
+	entry.expires = time.Now().Add(-time.Second)
+	remoteSchemas.entries[key] = entry
+	remoteSchemas.Unlock()
+
+	start = time.Now()
+	stale, err := LoadSchema(context.Background(), project)
+	if err != nil || stale != schema {
+		t.Fatalf("Expected the expired schema to be returned, got %v, %v", stale, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the expired schema without waiting, took %s", elapsed)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("Expected the expired schema to be refreshed, got %d requests", n)
+	}
+}
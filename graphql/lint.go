@@ -0,0 +1,191 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Diagnostic severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// LintRules describes the rules checked by Lint, keyed by rule ID
+var LintRules = map[string]string{
+	"syntax":                "Documents must be valid GraphQL",
+	"fragments":             "Fragment spreads must resolve to exactly one fragment definition",
+	"metadata":              "Tool metadata in @mcp directives and comment tags must be well-formed",
+	"schema":                "A schema is needed to validate operations",
+	"validation":            "Operations must be valid against the schema",
+	"duplicate-operation":   "Tool names, the operation's name or the one set in its metadata, must be unique across a project's documents",
+	"anonymous-operation":   "Operations need a name to become tools",
+	"missing-description":   "Operations should have a description comment",
+	"unused-fragment":       "Fragments should be used by at least one operation",
+	"mutation-confirmation": "Mutations should declare whether they are destructive",
+}
+
+// Diagnostic is a problem found in a project's documents
+type Diagnostic struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Project  string `json:"project,omitempty"` // Only set for multi-project configs
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// Lint checks the documents of every project in the config: syntax, fragments and schema validation,
+// plus the rules that make operations usable as tools.
+// Problems are returned as diagnostics; the error is only set when a project cannot be checked at all.
+func Lint(ctx context.Context, config *GraphQLConfig) ([]Diagnostic, error) {
+	var diagnostics []Diagnostic
+
+	projects := config.Projects()
+	for _, project := range projects {
+		projectDiagnostics, err := lintProject(ctx, project)
+		if err != nil {
+			if len(projects) > 1 {
+				return nil, fmt.Errorf("project %s: %w", project.Name, err)
+			}
+			return nil, err
+		}
+		for i := range projectDiagnostics {
+			if len(projects) > 1 {
+				projectDiagnostics[i].Project = project.Name
+			}
+		}
+		diagnostics = append(diagnostics, projectDiagnostics...)
+	}
+
+	return diagnostics, nil
+}
+
+func lintProject(ctx context.Context, project *GraphQLProject) ([]Diagnostic, error) {
+	index, err := IndexDocuments(project)
+	if err != nil {
+		var gqlErr *gqlerror.Error
+		if errors.As(err, &gqlErr) {
+			return []Diagnostic{errorDiagnostic("syntax", SeverityError, gqlErr)}, nil
+		}
+		return nil, err
+	}
+
+	var diagnostics []Diagnostic
+	for _, err := range index.Errors {
+		diagnostics = append(diagnostics, errorDiagnostic("fragments", SeverityError, err))
+	}
+
+	schema, err := LoadSchema(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	if schema == nil {
+		diagnostics = append(diagnostics, Diagnostic{
+			Rule:     "schema",
+			Severity: SeverityWarning,
+			Message:  "No schema available, operations are not validated",
+		})
+	}
+
+	tools := make(map[string]*ast.OperationDefinition) // Operations by tool name
+	usedFragments := make(map[string]bool)
+	for _, file := range index.Files {
+		for _, op := range file.Doc.Operations {
+			comment := operationComment(file, op)
+			metadata, metadataErr := parseMetadata(op, comment)
+			if metadataErr != nil {
+				diagnostics = append(diagnostics, errorDiagnostic("metadata", SeverityError, metadataErr))
+			}
+
+			doc := index.Resolve(op)
+			for _, fragment := range doc.Fragments {
+				usedFragments[fragment.Name] = true
+			}
+
+			if schema != nil && validationMode(project) != ValidationOff {
+				for _, err := range ValidateOperation(schema, doc) {
+					diagnostics = append(diagnostics, errorDiagnostic("validation", SeverityError, err))
+				}
+			}
+
+			if op.Name == "" {
+				diagnostics = append(diagnostics, positionDiagnostic("anonymous-operation", SeverityError, op.Position,
+					"Anonymous operation cannot be used as a tool, give it a name"))
+				continue
+			}
+
+			name := op.Name
+			if metadata.Name != "" {
+				name = metadata.Name
+			}
+			if existing, exists := tools[name]; exists {
+				message := fmt.Sprintf("Operation %q is already defined at %s", op.Name, formatPosition(existing.Position))
+				if name != op.Name || existing.Name != op.Name {
+					message = fmt.Sprintf("Tool %q of operation %q is already defined by operation %q at %s",
+						name, op.Name, existing.Name, formatPosition(existing.Position))
+				}
+				diagnostics = append(diagnostics, positionDiagnostic("duplicate-operation", SeverityError, op.Position, message))
+			} else {
+				tools[name] = op
+			}
+
+			if metadata.Description == "" && operationDescription(file, op, schema) == "" {
+				diagnostics = append(diagnostics, positionDiagnostic("missing-description", SeverityWarning, op.Position,
+					fmt.Sprintf("Operation %q has no description, add a # comment above it", op.Name)))
+			}
+			if op.Operation == ast.Mutation && metadata.Destructive == nil {
+				diagnostics = append(diagnostics, positionDiagnostic("mutation-confirmation", SeverityWarning, op.Position,
+					fmt.Sprintf("Mutation %q does not declare whether it is destructive, add @%s(destructive: true|false) or a \"# @destructive true|false\" comment", op.Name, MetadataDirective)))
+			}
+		}
+	}
+
+	for _, file := range index.Files {
+		for _, fragment := range file.Doc.Fragments {
+			if !usedFragments[fragment.Name] {
+				diagnostics = append(diagnostics, positionDiagnostic("unused-fragment", SeverityWarning, fragment.Position,
+					fmt.Sprintf("Fragment %q is not used by any operation", fragment.Name)))
+			}
+		}
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return diagnostics, nil
+}
+
+// errorDiagnostic converts a gqlparser error, which carries its file in the "file" extension
+func errorDiagnostic(rule, severity string, err *gqlerror.Error) Diagnostic {
+	diagnostic := Diagnostic{Rule: rule, Severity: severity, Message: err.Message}
+	diagnostic.File, _ = err.Extensions["file"].(string)
+	if len(err.Locations) > 0 {
+		diagnostic.Line = err.Locations[0].Line
+		diagnostic.Column = err.Locations[0].Column
+	}
+	return diagnostic
+}
+
+func positionDiagnostic(rule, severity string, pos *ast.Position, message string) Diagnostic {
+	diagnostic := Diagnostic{Rule: rule, Severity: severity, Message: message}
+	if pos != nil {
+		diagnostic.File = pos.Src.Name
+		diagnostic.Line = pos.Line
+		diagnostic.Column = pos.Column
+	}
+	return diagnostic
+}
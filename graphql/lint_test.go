@@ -0,0 +1,155 @@
+package graphql
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tempDir := t.TempDir()
+
+	schemaContent := `
+type Query {
+  film(id: ID!): Film
+}
+
+type Mutation {
+  deleteFilm(id: ID!): Boolean
+}
+
+type Film {
+  title: String
+}
+`
+	filmsContent := `# Get a film by ID
+query GetFilm($id: ID!) {
+  film(id: $id) {
+    ...FilmFields
+  }
+}
+
+query GetFilmTypo($id: ID!) {
+  film(id: $id) {
+    titel
+  }
+}
+
+{
+  film(id: "1") {
+    title
+  }
+}
+
+# Delete a film
+mutation DeleteFilm($id: ID!) {
+  deleteFilm(id: $id)
+}
+
+fragment FilmFields on Film {
+  title
+}
+
+fragment UnusedFields on Film {
+  title
+}
+`
+	otherContent := `# Get a film, again
+# @destructive false
+query GetFilm($id: ID!) {
+  film(id: $id) {
+    title
+  }
+}
+
+# Find a film
+query FindFilm($id: ID!) @mcp(name: "film") {
+  film(id: $id) {
+    title
+  }
+}
+
+# Look up a film
+query LookupFilm($id: ID!) @mcp(name: "film") {
+  film(id: $id) {
+    title
+  }
+}
+`
+	schemaPath := filepath.Join(tempDir, "schema.graphql")
+	filmsPath := filepath.Join(tempDir, "ops", "films.graphql")
+	otherPath := filepath.Join(tempDir, "ops", "other.graphql")
+	if err := os.MkdirAll(filepath.Dir(filmsPath), 0755); err != nil {
+		t.Fatalf("Failed to create operations directory: %v", err)
+	}
+	for path, content := range map[string]string{schemaPath: schemaContent, filmsPath: filmsContent, otherPath: otherContent} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", path, err)
+		}
+	}
+
+	config := &GraphQLConfig{
+		SingleProject: &GraphQLProject{
+			Schema:    []SchemaPointer{{URL: schemaPath}},
+			Documents: []string{filepath.Join(tempDir, "ops")},
+		},
+	}
+
+	diagnostics, err := Lint(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Lint returned an error: %v", err)
+	}
+
+	type key struct {
+		rule string
+		file string
+		line int
+	}
+	expected := map[key]string{
+		{"missing-description", filmsPath, 8}:    SeverityWarning,
+		{"validation", filmsPath, 10}:            SeverityError,
+		{"anonymous-operation", filmsPath, 14}:   SeverityError,
+		{"mutation-confirmation", filmsPath, 21}: SeverityWarning,
+		{"unused-fragment", filmsPath, 29}:       SeverityWarning,
+		{"duplicate-operation", otherPath, 3}:    SeverityError,
+		{"duplicate-operation", otherPath, 17}:   SeverityError,
+	}
+
+	got := map[key]string{}
+	for _, d := range diagnostics {
+		got[key{d.Rule, d.File, d.Line}] = d.Severity
+	}
+	for k, severity := range expected {
+		if got[k] != severity {
+			t.Errorf("Expected %s %s at %s:%d, got diagnostics: %+v", severity, k.rule, k.file, k.line, diagnostics)
+		}
+	}
+	for _, d := range diagnostics {
+		if d.Rule == "duplicate-operation" && d.Line == 17 && !strings.Contains(d.Message, `Tool "film" of operation "LookupFilm" is already defined by operation "FindFilm"`) {
+			t.Errorf("Expected the duplicate tool name to be reported, got %q", d.Message)
+		}
+	}
+	if len(diagnostics) != len(expected) {
+		t.Errorf("Expected %d diagnostics, got %d: %+v", len(expected), len(diagnostics), diagnostics)
+	}
+}
+
+func TestParseDocComment(t *testing.T) {
+	src := `# Not part of the comment
+
+# Create a film.
+# Requires an admin token.
+# @destructive false
+# @confirm
+mutation CreateFilm { createFilm }
+`
+	comment := parseDocComment(leadingComment(src, 7))
+	if comment.Description != "Create a film.\nRequires an admin token." {
+		t.Errorf("Unexpected description: %q", comment.Description)
+	}
+	if comment.Tags["destructive"] != "false" || comment.Tags["confirm"] != "true" {
+		t.Errorf("Unexpected tags: %v", comment.Tags)
+	}
+}
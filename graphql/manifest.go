@@ -0,0 +1,123 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+)
+
+// TrustedDocumentsOptions make calls send only the ID of the operation's document, under
+// `extensions.gqai.trustedDocuments`, for endpoints that only run documents registered from a manifest
+type TrustedDocumentsOptions struct {
+	Enabled  bool   `yaml:"enabled"`
+	Protocol string `yaml:"protocol"` // How the ID is sent: documentId (default) or apq, as an Apollo persisted query hash
+	Prefix   string `yaml:"prefix"`   // Prepended to the hash in documentId, e.g. "sha256:"
+}
+
+// Trusted document protocols
+const (
+	TrustedDocumentID  = "documentId"
+	TrustedDocumentAPQ = "apq"
+)
+
+// request replaces the query of the request with the ID of its document
+func (o TrustedDocumentsOptions) request(request graphqlRequest) graphqlRequest {
+	id := o.documentID(request.Query)
+	request.Query = ""
+	if o.Protocol == TrustedDocumentAPQ {
+		request.Extensions = map[string]any{
+			"persistedQuery": map[string]any{"version": 1, "sha256Hash": id},
+		}
+	} else {
+		request.DocumentID = id
+	}
+	return request
+}
+
+// documentID is the ID a document is sent and registered as: its hash, with the prefix in documentId requests
+func (o TrustedDocumentsOptions) documentID(document string) string {
+	if o.Protocol == TrustedDocumentAPQ {
+		return QueryHash(document)
+	}
+	return o.Prefix + QueryHash(document)
+}
+
+func checkTrustedDocuments(options TrustedDocumentsOptions) error {
+	switch options.Protocol {
+	case "", TrustedDocumentID, TrustedDocumentAPQ:
+		return nil
+	default:
+		return fmt.Errorf("invalid extensions.gqai.trustedDocuments.protocol %q: must be %s or %s", options.Protocol, TrustedDocumentID, TrustedDocumentAPQ)
+	}
+}
+
+// Manifest formats
+const (
+	ManifestApollo = "apollo" // Apollo persisted query manifest
+	ManifestHive   = "hive"   // GraphQL Hive persisted documents, a map of hash to document
+	ManifestRelay  = "relay"  // Relay persisted queries, a map of hash to document
+)
+
+// ApolloManifest is the Apollo persisted query manifest format
+type ApolloManifest struct {
+	Format     string                    `json:"format"`
+	Version    int                       `json:"version"`
+	Operations []ApolloManifestOperation `json:"operations"`
+}
+
+type ApolloManifestOperation struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Body string `json:"body"`
+}
+
+// Manifest returns the persisted document manifest of the operations in the given format.
+// Documents are identified by the SHA-256 hash of the exact text gqai sends for them,
+// with the `trustedDocuments.prefix` of their project, so that the IDs match those sent at runtime.
+func Manifest(ops map[string]*Operation, format string) (any, error) {
+	sorted := make([]*Operation, 0, len(ops))
+	for _, op := range ops {
+		sorted = append(sorted, op)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	switch format {
+	case ManifestApollo:
+		manifest := ApolloManifest{
+			Format:     "apollo-persisted-query-manifest",
+			Version:    1,
+			Operations: []ApolloManifestOperation{},
+		}
+		seen := map[string]bool{}
+		for _, op := range sorted {
+			id := op.trustedDocumentID()
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			manifest.Operations = append(manifest.Operations, ApolloManifestOperation{
+				ID:   id,
+				Name: op.Name,
+				Type: op.OperationType,
+				Body: op.Document(),
+			})
+		}
+		return manifest, nil
+	case ManifestHive, ManifestRelay:
+		manifest := map[string]string{}
+		for _, op := range sorted {
+			manifest[op.trustedDocumentID()] = op.Document()
+		}
+		return manifest, nil
+	default:
+		return nil, fmt.Errorf("unknown manifest format %q: must be %s, %s or %s", format, ManifestApollo, ManifestHive, ManifestRelay)
+	}
+}
+
+func (op *Operation) trustedDocumentID() string {
+	var options TrustedDocumentsOptions
+	if op.Project != nil {
+		options = op.Project.Options.TrustedDocuments
+	}
+	return options.documentID(op.Document())
+}
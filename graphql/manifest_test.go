@@ -0,0 +1,127 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	ops := map[string]*Operation{
+		"get_film":  {Name: "GetFilm", OperationType: "query", Query: "query GetFilm {\n\tfilm\n}\n"},
+		"rate_film": {Name: "RateFilm", OperationType: "mutation", Query: "mutation RateFilm {\n\trate\n}\n"},
+	}
+	filmHash := QueryHash(ops["get_film"].Query)
+	rateHash := QueryHash(ops["rate_film"].Query)
+
+	manifest, err := Manifest(ops, ManifestApollo)
+	if err != nil {
+		t.Fatalf("Manifest returned an error: %v", err)
+	}
+	out, _ := json.Marshal(manifest)
+	expected := `{"format":"apollo-persisted-query-manifest","version":1,"operations":[` +
+		`{"id":"` + filmHash + `","name":"GetFilm","type":"query","body":"query GetFilm {\n\tfilm\n}\n"},` +
+		`{"id":"` + rateHash + `","name":"RateFilm","type":"mutation","body":"mutation RateFilm {\n\trate\n}\n"}]}`
+	if string(out) != expected {
+		t.Errorf("Unexpected Apollo manifest:\n got: %s\nwant: %s", out, expected)
+	}
+
+	for _, format := range []string{ManifestHive, ManifestRelay} {
+		manifest, err := Manifest(ops, format)
+		if err != nil {
+			t.Fatalf("Manifest returned an error: %v", err)
+		}
+		documents := manifest.(map[string]string)
+		if len(documents) != 2 || documents[filmHash] != ops["get_film"].Query || documents[rateHash] != ops["rate_film"].Query {
+			t.Errorf("Unexpected %s manifest: %v", format, documents)
+		}
+	}
+
+	if _, err := Manifest(ops, "persisted"); err == nil {
+		t.Error("Expected an error for an unknown format, got nil")
+	}
+}
+
+func TestManifestPrefix(t *testing.T) {
+	var documentID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body graphqlRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		documentID = body.DocumentID
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data": {"film": "A New Hope"}}`)
+	}))
+	defer server.Close()
+
+	project := &GraphQLProject{Options: ProjectOptions{
+		TrustedDocuments: TrustedDocumentsOptions{Enabled: true, Prefix: "sha256:"},
+	}}
+	op := &Operation{Name: "GetFilm", OperationType: "query", Query: "query GetFilm {\n\tfilm\n}\n", Project: project}
+
+	// The ID sent at runtime is the one registered from the manifest
+	if _, err := Execute(context.Background(), server.URL, nil, op, nil); err != nil {
+		t.Fatalf("Execute returned an error: %v", err)
+	}
+	for _, format := range []string{ManifestApollo, ManifestHive} {
+		manifest, err := Manifest(map[string]*Operation{"GetFilm": op}, format)
+		if err != nil {
+			t.Fatalf("Manifest returned an error: %v", err)
+		}
+		var id string
+		switch m := manifest.(type) {
+		case ApolloManifest:
+			id = m.Operations[0].ID
+		case map[string]string:
+			for key := range m {
+				id = key
+			}
+		}
+		if id != documentID || id != "sha256:"+QueryHash(op.Query) {
+			t.Errorf("Expected the %s manifest ID to be the one sent, %q, got %q", format, documentID, id)
+		}
+	}
+}
+
+func TestExecuteTrustedDocuments(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = nil
+		json.Unmarshal(data, &body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data": {"test": "success"}}`)
+	}))
+	defer server.Close()
+
+	op := &Operation{Name: "TestQuery", Query: "query TestQuery { test }"}
+	hash := QueryHash(op.Query)
+
+	tests := []struct {
+		options  TrustedDocumentsOptions
+		expected string
+	}{
+		{
+			options:  TrustedDocumentsOptions{Enabled: true, Prefix: "sha256:"},
+			expected: `{"documentId":"sha256:` + hash + `","operationName":"TestQuery","variables":{"id":"1"}}`,
+		},
+		{
+			options: TrustedDocumentsOptions{Enabled: true, Protocol: TrustedDocumentAPQ},
+			expected: `{"extensions":{"persistedQuery":{"sha256Hash":"` + hash + `","version":1}},` +
+				`"operationName":"TestQuery","variables":{"id":"1"}}`,
+		},
+	}
+	for _, tt := range tests {
+		// The query text is never sent, only the ID of the document
+		op.Project = &GraphQLProject{Options: ProjectOptions{TrustedDocuments: tt.options}}
+		if _, err := Execute(context.Background(), server.URL, map[string]any{"id": "1"}, op, nil); err != nil {
+			t.Fatalf("Execute returned an error: %v", err)
+		}
+		if got, _ := json.Marshal(body); string(got) != tt.expected {
+			t.Errorf("Unexpected request:\n got: %s\nwant: %s", got, tt.expected)
+		}
+	}
+}
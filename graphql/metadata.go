@@ -0,0 +1,170 @@
+package graphql
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// MetadataDirective is the client-side directive holding tool metadata, e.g.
+// `mutation DeleteFilm($id: ID! @mcp(description: "Film to delete")) @mcp(destructive: true) { ... }`.
+// It is removed from the operation before the operation is validated and sent.
+const MetadataDirective = "mcp"
+
+// ToolMetadata overrides what is derived from an operation when it is exposed as a tool.
+// Hints left nil fall back to the defaults of the operation type.
+type ToolMetadata struct {
+	Name        string
+	Title       string
+	Description string
+	ReadOnly    *bool
+	Destructive *bool
+	Idempotent  *bool
+	OpenWorld   *bool
+	Timeout     time.Duration     // Overrides the project's timeout
+	Retry       *bool             // Opts the operation in or out of retries, see RetryOptions
+	MaxAttempts int               // Overrides the project's retry.maxAttempts
+	Method      string            // Overrides the project's method, GET or POST
+	Arguments   map[string]string // Descriptions of the tool arguments, keyed by variable name
+}
+
+// parseMetadata reads the tool metadata of an operation from its doc comment tags and its `@mcp` directives,
+// the directive winning when both set a value. The `@mcp` directives are removed from the operation.
+func parseMetadata(op *ast.OperationDefinition, comment DocComment) (ToolMetadata, *gqlerror.Error) {
+	metadata := ToolMetadata{
+		Name:        comment.Tags["name"],
+		Title:       comment.Tags["title"],
+		Description: comment.Tags["description"],
+		Arguments:   map[string]string{},
+	}
+	for name, description := range comment.Args {
+		metadata.Arguments[name] = description
+	}
+
+	hints := map[string]**bool{
+		"readOnly":    &metadata.ReadOnly,
+		"destructive": &metadata.Destructive,
+		"idempotent":  &metadata.Idempotent,
+		"openWorld":   &metadata.OpenWorld,
+		"retry":       &metadata.Retry,
+	}
+	for tag, hint := range hints {
+		value, ok := comment.Tags[tag]
+		if !ok {
+			continue
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return metadata, gqlerror.ErrorPosf(op.Position, "Tag @%s must be true or false, got %q", tag, value)
+		}
+		*hint = &b
+	}
+
+	if value, ok := comment.Tags["timeout"]; ok {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return metadata, gqlerror.ErrorPosf(op.Position, "Tag @timeout must be a positive duration such as 30s, got %q", value)
+		}
+		metadata.Timeout = timeout
+	}
+
+	if value, ok := comment.Tags["maxAttempts"]; ok {
+		attempts, err := strconv.Atoi(value)
+		if err != nil || attempts <= 0 {
+			return metadata, gqlerror.ErrorPosf(op.Position, "Tag @maxAttempts must be a positive integer, got %q", value)
+		}
+		metadata.MaxAttempts = attempts
+	}
+
+	if value, ok := comment.Tags["method"]; ok {
+		method, ok := parseMethod(value)
+		if !ok {
+			return metadata, gqlerror.ErrorPosf(op.Position, "Tag @method must be GET or POST, got %q", value)
+		}
+		metadata.Method = method
+	}
+
+	var directives ast.DirectiveList
+	directives, op.Directives = splitMetadataDirectives(op.Directives)
+	for _, directive := range directives {
+		for _, arg := range directive.Arguments {
+			switch arg.Name {
+			case "name", "title", "description":
+				if arg.Value.Kind != ast.StringValue && arg.Value.Kind != ast.BlockValue {
+					return metadata, gqlerror.ErrorPosf(arg.Position, "Argument %q of @%s must be a string", arg.Name, MetadataDirective)
+				}
+				switch arg.Name {
+				case "name":
+					metadata.Name = arg.Value.Raw
+				case "title":
+					metadata.Title = arg.Value.Raw
+				case "description":
+					metadata.Description = arg.Value.Raw
+				}
+			case "readOnly", "destructive", "idempotent", "openWorld", "retry":
+				if arg.Value.Kind != ast.BooleanValue {
+					return metadata, gqlerror.ErrorPosf(arg.Position, "Argument %q of @%s must be a boolean", arg.Name, MetadataDirective)
+				}
+				b := arg.Value.Raw == "true"
+				*hints[arg.Name] = &b
+			case "timeout":
+				timeout, err := time.ParseDuration(arg.Value.Raw)
+				if arg.Value.Kind != ast.StringValue || err != nil || timeout <= 0 {
+					return metadata, gqlerror.ErrorPosf(arg.Position, "Argument %q of @%s must be a positive duration such as \"30s\"", arg.Name, MetadataDirective)
+				}
+				metadata.Timeout = timeout
+			case "maxAttempts":
+				attempts, err := strconv.Atoi(arg.Value.Raw)
+				if arg.Value.Kind != ast.IntValue || err != nil || attempts <= 0 {
+					return metadata, gqlerror.ErrorPosf(arg.Position, "Argument %q of @%s must be a positive integer", arg.Name, MetadataDirective)
+				}
+				metadata.MaxAttempts = attempts
+			case "method":
+				method, ok := parseMethod(arg.Value.Raw)
+				if arg.Value.Kind != ast.StringValue || !ok {
+					return metadata, gqlerror.ErrorPosf(arg.Position, "Argument %q of @%s must be \"GET\" or \"POST\"", arg.Name, MetadataDirective)
+				}
+				metadata.Method = method
+			default:
+				return metadata, gqlerror.ErrorPosf(arg.Position, "Unknown argument %q on @%s", arg.Name, MetadataDirective)
+			}
+		}
+	}
+
+	// Only queries are safe to send with GET, which caches and proxies may repeat
+	if metadata.Method == http.MethodGet && op.Operation != ast.Query {
+		return metadata, gqlerror.ErrorPosf(op.Position, "Operation %s is a %s, it cannot be sent with GET", op.Name, op.Operation)
+	}
+
+	for _, variable := range op.VariableDefinitions {
+		directives, variable.Directives = splitMetadataDirectives(variable.Directives)
+		for _, directive := range directives {
+			for _, arg := range directive.Arguments {
+				if arg.Name != "description" {
+					return metadata, gqlerror.ErrorPosf(arg.Position, "Unknown argument %q on @%s of $%s, only description is allowed", arg.Name, MetadataDirective, variable.Variable)
+				}
+				if arg.Value.Kind != ast.StringValue && arg.Value.Kind != ast.BlockValue {
+					return metadata, gqlerror.ErrorPosf(arg.Position, "Argument %q of @%s must be a string", arg.Name, MetadataDirective)
+				}
+				metadata.Arguments[variable.Variable] = arg.Value.Raw
+			}
+		}
+	}
+
+	return metadata, nil
+}
+
+// splitMetadataDirectives separates the `@mcp` directives from the others
+func splitMetadataDirectives(directives ast.DirectiveList) (metadata, others ast.DirectiveList) {
+	for _, directive := range directives {
+		if directive.Name == MetadataDirective {
+			metadata = append(metadata, directive)
+		} else {
+			others = append(others, directive)
+		}
+	}
+	return metadata, others
+}
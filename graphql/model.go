@@ -1,9 +1,18 @@
 package graphql
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
 // graphqlRequest represents a GraphQL graphqlRequest.
 type graphqlRequest struct {
-	Query     string                 `json:"query"`
-	Variables map[string]interface{} `json:"variables"`
+	Query         string                 `json:"query,omitempty"` // Left out when only the persisted query hash is sent
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+	DocumentID    string                 `json:"documentId,omitempty"` // Sent instead of the query to endpoints that only accept trusted documents
 }
 
 // Response represents a GraphQL response.
@@ -14,5 +23,49 @@ type response struct {
 
 // Error represents a GraphQL error.
 type graphqlError struct {
-	Message string `json:"message"`
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// String formats the error with its path and code, e.g. `Not allowed (path: film.director, code: FORBIDDEN)`
+func (e graphqlError) String() string {
+	var details []string
+	if len(e.Path) > 0 {
+		segments := make([]string, len(e.Path))
+		for i, segment := range e.Path {
+			segments[i] = fmt.Sprint(segment)
+		}
+		details = append(details, "path: "+strings.Join(segments, "."))
+	}
+	if code, ok := e.Extensions["code"]; ok {
+		details = append(details, fmt.Sprintf("code: %v", code))
+	}
+	if len(details) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, strings.Join(details, ", "))
+}
+
+// ResponseErrors returns the errors of a GraphQL response returned by Execute, formatted with their path and code,
+// and whether the response has data alongside them
+func ResponseErrors(result any) (errors []string, hasData bool) {
+	resp, ok := result.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	hasData = resp["data"] != nil
+
+	raw, err := json.Marshal(resp["errors"])
+	if err != nil {
+		return nil, hasData
+	}
+	var errs []graphqlError
+	if err := json.Unmarshal(raw, &errs); err != nil {
+		return []string{fmt.Sprintf("%v", resp["errors"])}, hasData
+	}
+	for _, e := range errs {
+		errors = append(errors, e.String())
+	}
+	return errors, hasData
 }
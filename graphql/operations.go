@@ -1,58 +1,202 @@
 package graphql
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"io/fs"
-	"os"
-	"path/filepath"
+	"log"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/vektah/gqlparser/v2/ast"
-	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 type Operation struct {
 	Name          string
-	Doc           *ast.QueryDocument
-	Raw           string
+	Description   string             // From the comment or block description above the operation, or the schema
+	Doc           *ast.QueryDocument // The operation and the fragments it spreads
+	Raw           string             // Contents of the file the operation is defined in
+	Query         string             // Minimal document sent to the backend
 	OperationType string
+	Metadata      ToolMetadata // Overrides from the operation's `@mcp` directive and comment tags
+	Project       *GraphQLProject
+	Schema        *ast.Schema // Typed schema of the project, nil when none is available
 }
 
-func LoadOperations(config *GraphQLConfig) (map[string]*Operation, error) {
+// LoadOperations loads the operations of every project in the config, keyed by tool name.
+// When the config serves more than one project, tool names are namespaced as `<project>_<operation>`;
+// namespaced names can still collide (project `a_b` with operation `C` and project `a` with operation `b_C`),
+// in which case the project that sorts last wins, like duplicate tools within a project.
+func LoadOperations(ctx context.Context, config *GraphQLConfig) (map[string]*Operation, error) {
 	opMap := make(map[string]*Operation)
 
-	err := filepath.WalkDir(config.SingleProject.Documents[0], func(path string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() || filepath.Ext(path) != ".graphql" {
-			return nil
-		}
-
-		data, err := os.ReadFile(path)
+	projects := config.Projects()
+	for _, project := range projects {
+		ops, err := LoadProjectOperations(ctx, project)
 		if err != nil {
-			return err
+			if len(projects) > 1 {
+				return nil, fmt.Errorf("project %s: %w", project.Name, err)
+			}
+			return nil, err
 		}
 
-		doc, parseErr := parser.ParseQuery(&ast.Source{
-			Name:  filepath.Base(path),
-			Input: string(data),
-		})
-		if parseErr != nil {
-			return parseErr
+		for name, op := range ops {
+			if len(projects) > 1 {
+				name = project.Name + "_" + name
+			}
+			if existing, exists := opMap[name]; exists {
+				log.Printf("Warning: tool %s is defined by both %s in project %s and %s in project %s, using the latter",
+					name, existing.Name, existing.Project.Name, op.Name, project.Name)
+			}
+			opMap[name] = op
 		}
+	}
+
+	return opMap, nil
+}
+
+// LoadProjectOperations loads the operations of a single project, keyed by operation name
+// (or the tool name set in the operation's metadata).
+// Fragments are resolved across all of the project's documents; missing or duplicate fragments are an error.
+func LoadProjectOperations(ctx context.Context, project *GraphQLProject) (map[string]*Operation, error) {
+	index, err := IndexDocuments(project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load operations: %v", err)
+	}
+	if len(index.Errors) > 0 {
+		return nil, fmt.Errorf("failed to load operations:\n%s", strings.TrimSpace(index.Errors.Error()))
+	}
+
+	schema, err := LoadSchema(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := validationMode(project)
+
+	var invalid gqlerror.List
+	opMap := make(map[string]*Operation)
+	for _, file := range index.Files {
+		for _, op := range file.Doc.Operations {
+			if op.Name == "" {
+				log.Printf("Warning: skipping anonymous operation at %s, give it a name to use it as a tool", formatPosition(op.Position))
+				continue
+			}
+
+			metadata, metadataErr := parseMetadata(op, operationComment(file, op))
+			doc := index.Resolve(op)
+
+			var errs gqlerror.List
+			if metadataErr != nil {
+				errs = append(errs, metadataErr)
+			}
+			if schema != nil && mode != ValidationOff {
+				errs = append(errs, ValidateOperation(schema, doc)...)
+			}
+			if len(errs) > 0 {
+				if mode == ValidationStrict {
+					invalid = append(invalid, errs...)
+				} else {
+					log.Printf("Warning: skipping invalid operation %s:\n%s", op.Name, strings.TrimSpace(errs.Error()))
+				}
+				continue
+			}
+
+			description := metadata.Description
+			if description == "" {
+				description = operationDescription(file, op, schema)
+			}
 
-		for _, op := range doc.Operations {
-			opMap[op.Name] = &Operation{
+			name := op.Name
+			if metadata.Name != "" {
+				name = metadata.Name
+			}
+			if existing, exists := opMap[name]; exists {
+				log.Printf("Warning: tool %s is defined by both %s and %s, using the latter", name, existing.Name, op.Name)
+			}
+
+			opMap[name] = &Operation{
 				Name:          op.Name,
+				Description:   description,
 				Doc:           doc,
-				Raw:           string(data),
+				Raw:           file.Raw,
+				Query:         formatQueryDocument(doc),
 				OperationType: string(op.Operation),
+				Metadata:      metadata,
+				Project:       project,
+				Schema:        schema,
 			}
 		}
+	}
 
-		return nil
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to load operations: %v", err)
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid operations:\n%s", strings.TrimSpace(invalid.Error()))
 	}
 
 	return opMap, nil
 }
+
+// DefaultTimeout is the timeout of tool calls when neither the project nor the operation sets one
+const DefaultTimeout = 30 * time.Second
+
+// Timeout returns how long a call of the operation may take: the operation's own timeout,
+// else the project's, else DefaultTimeout
+func (op *Operation) Timeout() time.Duration {
+	if op.Metadata.Timeout > 0 {
+		return op.Metadata.Timeout
+	}
+	if op.Project != nil {
+		return op.Project.Timeout()
+	}
+	return DefaultTimeout
+}
+
+// Timeout returns how long a call of the project's operations may take, unless an operation sets its own
+func (p *GraphQLProject) Timeout() time.Duration {
+	if p.Options.Timeout > 0 {
+		return p.Options.Timeout
+	}
+	return DefaultTimeout
+}
+
+// Method returns the HTTP method the operation is sent with: GET when the operation or its project asks for it
+// and the operation is a query, POST otherwise
+func (op *Operation) Method() string {
+	method := op.Metadata.Method
+	if method == "" && op.Project != nil {
+		method = op.Project.Options.Method
+	}
+	if method == http.MethodGet && op.isQuery() {
+		return http.MethodGet
+	}
+	return http.MethodPost
+}
+
+// parseMethod normalizes an HTTP method setting, which must be GET or POST
+func parseMethod(value string) (string, bool) {
+	method := strings.ToUpper(value)
+	return method, method == http.MethodGet || method == http.MethodPost
+}
+
+// Document returns the document sent to the backend: the minimal document when available, the whole file otherwise
+func (op *Operation) Document() string {
+	if op.Query != "" {
+		return op.Query
+	}
+	return op.Raw
+}
+
+// isQuery reports whether the operation is a query, the only operations that are safe to repeat and cache
+func (op *Operation) isQuery() bool {
+	return op.OperationType == "" || op.OperationType == string(ast.Query)
+}
+
+// formatQueryDocument prints a query document back to GraphQL source
+func formatQueryDocument(doc *ast.QueryDocument) string {
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf).FormatQueryDocument(doc)
+	return buf.String()
+}
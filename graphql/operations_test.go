@@ -1,8 +1,12 @@
 package graphql
 
 import (
+	"bytes"
+	"context"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -39,7 +43,7 @@ query GetFilm($id: ID!) {
 	}
 
 	// Load operations
-	operations, err := LoadOperations(config)
+	operations, err := LoadOperations(context.Background(), config)
 	if err != nil {
 		t.Fatalf("LoadOperations returned an error: %v", err)
 	}
@@ -64,3 +68,323 @@ query GetFilm($id: ID!) {
 		t.Fatalf("Expected operation type to be query, got %s", operation.OperationType)
 	}
 }
+
+func TestLoadOperationsMinimalDocument(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Two operations in one file, sharing fragments defined in another file
+	queryContent := `
+query GetFilm($id: ID!) {
+  film(id: $id) {
+    ...FilmFields
+  }
+}
+
+query GetPerson($id: ID!) {
+  person(id: $id) {
+    ...PersonFields
+  }
+}
+`
+	fragmentContent := `
+fragment FilmFields on Film {
+  title
+  director
+  characters {
+    ...PersonFields
+  }
+}
+
+fragment PersonFields on Person {
+  name
+}
+
+fragment UnusedFields on Film {
+  releaseDate
+}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "queries.graphql"), []byte(queryContent), 0644); err != nil {
+		t.Fatalf("Failed to create sample GraphQL file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "fragments.graphql"), []byte(fragmentContent), 0644); err != nil {
+		t.Fatalf("Failed to create sample GraphQL file: %v", err)
+	}
+
+	config := &GraphQLConfig{
+		SingleProject: &GraphQLProject{
+			Documents: []string{tempDir},
+		},
+	}
+
+	operations, err := LoadOperations(context.Background(), config)
+	if err != nil {
+		t.Fatalf("LoadOperations returned an error: %v", err)
+	}
+	if len(operations) != 2 {
+		t.Fatalf("Expected 2 operations, got %d", len(operations))
+	}
+
+	// GetFilm carries its own definition plus the transitive closure of its fragments
+	getFilm := operations["GetFilm"]
+	if len(getFilm.Doc.Operations) != 1 {
+		t.Fatalf("Expected 1 operation in the GetFilm document, got %d", len(getFilm.Doc.Operations))
+	}
+	var fragmentNames []string
+	for _, fragment := range getFilm.Doc.Fragments {
+		fragmentNames = append(fragmentNames, fragment.Name)
+	}
+	if len(fragmentNames) != 2 || fragmentNames[0] != "FilmFields" || fragmentNames[1] != "PersonFields" {
+		t.Fatalf("Expected GetFilm fragments to be [FilmFields PersonFields], got %v", fragmentNames)
+	}
+	for _, want := range []string{"query GetFilm", "fragment FilmFields on Film", "fragment PersonFields on Person"} {
+		if !strings.Contains(getFilm.Query, want) {
+			t.Errorf("Expected GetFilm query to contain %q, got:\n%s", want, getFilm.Query)
+		}
+	}
+	for _, unwanted := range []string{"GetPerson", "UnusedFields"} {
+		if strings.Contains(getFilm.Query, unwanted) {
+			t.Errorf("Expected GetFilm query not to contain %q, got:\n%s", unwanted, getFilm.Query)
+		}
+	}
+
+	// GetPerson only needs PersonFields
+	getPerson := operations["GetPerson"]
+	if strings.Contains(getPerson.Query, "FilmFields") || !strings.Contains(getPerson.Query, "fragment PersonFields") {
+		t.Errorf("Expected GetPerson query to only contain PersonFields, got:\n%s", getPerson.Query)
+	}
+}
+
+func TestLoadOperationsValidation(t *testing.T) {
+	tempDir := t.TempDir()
+
+	schemaContent := `
+type Query {
+  film(id: ID!): Film
+}
+
+type Film {
+  title: String
+}
+`
+	queryContent := `query GetFilm($id: ID!) {
+  film(id: $id) {
+    title
+  }
+}
+
+query GetFilmTypo($id: ID!) {
+  film(id: $id) {
+    titel
+  }
+}
+`
+	schemaPath := filepath.Join(tempDir, "schema.graphql")
+	documentsDir := filepath.Join(tempDir, "operations")
+	queryPath := filepath.Join(documentsDir, "films.graphql")
+	if err := os.WriteFile(schemaPath, []byte(schemaContent), 0644); err != nil {
+		t.Fatalf("Failed to create schema file: %v", err)
+	}
+	if err := os.MkdirAll(documentsDir, 0755); err != nil {
+		t.Fatalf("Failed to create operations directory: %v", err)
+	}
+	if err := os.WriteFile(queryPath, []byte(queryContent), 0644); err != nil {
+		t.Fatalf("Failed to create sample GraphQL file: %v", err)
+	}
+
+	load := func(mode string) (map[string]*Operation, error) {
+		return LoadOperations(context.Background(), &GraphQLConfig{
+			SingleProject: &GraphQLProject{
+				Schema:    []SchemaPointer{{URL: schemaPath}},
+				Documents: []string{documentsDir},
+				Options:   ProjectOptions{Validation: mode},
+			},
+		})
+	}
+
+	// warn (the default) drops the invalid operation and keeps the others
+	operations, err := load("")
+	if err != nil {
+		t.Fatalf("LoadOperations returned an error: %v", err)
+	}
+	if _, ok := operations["GetFilm"]; !ok || len(operations) != 1 {
+		t.Fatalf("Expected only GetFilm to be loaded, got %d operations", len(operations))
+	}
+
+	// strict refuses to load, pointing at the offending field
+	_, err = load(ValidationStrict)
+	if err == nil {
+		t.Fatal("Expected an error in strict mode")
+	}
+	if !strings.Contains(err.Error(), queryPath+":9") || !strings.Contains(err.Error(), `"titel"`) {
+		t.Errorf("Expected the error to point at %s:9 and name the field, got: %v", queryPath, err)
+	}
+
+	// off loads every operation
+	operations, err = load(ValidationOff)
+	if err != nil {
+		t.Fatalf("LoadOperations returned an error: %v", err)
+	}
+	if len(operations) != 2 {
+		t.Fatalf("Expected 2 operations, got %d", len(operations))
+	}
+}
+
+func TestLoadOperationsDescriptions(t *testing.T) {
+	tempDir := t.TempDir()
+
+	schemaContent := `
+type Query {
+  "Look up a film by its ID"
+  film(id: ID!): Film
+  "All films"
+  films: [Film]
+}
+
+type Film {
+  title: String
+}
+`
+	queryContent := `# Get a film by ID.
+# Returns null when the film does not exist.
+query GetFilm($id: ID!) {
+  film(id: $id) { title }
+}
+
+"""
+  Get a film and the full catalog,
+  in one request.
+"""
+query GetFilmAndCatalog($id: ID!) {
+  film(id: $id) { title }
+  films { title }
+}
+
+query GetFilmUndocumented($id: ID!) {
+  film(id: $id) { title }
+}
+`
+	schemaPath := filepath.Join(tempDir, "schema.graphql")
+	documentsDir := filepath.Join(tempDir, "operations")
+	if err := os.WriteFile(schemaPath, []byte(schemaContent), 0644); err != nil {
+		t.Fatalf("Failed to create schema file: %v", err)
+	}
+	if err := os.MkdirAll(documentsDir, 0755); err != nil {
+		t.Fatalf("Failed to create operations directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(documentsDir, "films.graphql"), []byte(queryContent), 0644); err != nil {
+		t.Fatalf("Failed to create sample GraphQL file: %v", err)
+	}
+
+	operations, err := LoadOperations(context.Background(), &GraphQLConfig{
+		SingleProject: &GraphQLProject{
+			Schema:    []SchemaPointer{{URL: schemaPath}},
+			Documents: []string{documentsDir},
+			Options:   ProjectOptions{Validation: ValidationStrict},
+		},
+	})
+	if err != nil {
+		t.Fatalf("LoadOperations returned an error: %v", err)
+	}
+
+	expected := map[string]string{
+		"GetFilm":             "Get a film by ID.\nReturns null when the film does not exist.",
+		"GetFilmAndCatalog":   "Get a film and the full catalog,\nin one request.",
+		"GetFilmUndocumented": "Look up a film by its ID",
+	}
+	for name, description := range expected {
+		op, ok := operations[name]
+		if !ok {
+			t.Fatalf("%s operation not found", name)
+		}
+		if op.Description != description {
+			t.Errorf("Expected %s description %q, got %q", name, description, op.Description)
+		}
+	}
+
+	// The block description is not sent to the server
+	if strings.Contains(operations["GetFilmAndCatalog"].Query, "catalog") {
+		t.Errorf("Expected the description to be stripped from the query, got:\n%s", operations["GetFilmAndCatalog"].Query)
+	}
+}
+
+func TestLoadOperationsInvalidMetadata(t *testing.T) {
+	tempDir := t.TempDir()
+
+	queryContent := `query GetFilm @mcp(readOnly: "yes") {
+  film { title }
+}
+
+# @destructive maybe
+mutation DeleteFilm {
+  deleteFilm
+}
+
+query ListFilms @mcp(title: "List films") {
+  films { title }
+}
+
+mutation RateFilm @mcp(method: "GET") {
+  rateFilm
+}
+`
+	queryPath := filepath.Join(tempDir, "films.graphql")
+	if err := os.WriteFile(queryPath, []byte(queryContent), 0644); err != nil {
+		t.Fatalf("Failed to create sample GraphQL file: %v", err)
+	}
+
+	project := &GraphQLProject{Documents: []string{queryPath}}
+
+	// Without a schema, malformed metadata still drops the operation
+	operations, err := LoadProjectOperations(context.Background(), project)
+	if err != nil {
+		t.Fatalf("LoadProjectOperations returned an error: %v", err)
+	}
+	if len(operations) != 1 || operations["ListFilms"].Metadata.Title != "List films" {
+		t.Fatalf("Expected only ListFilms to be loaded, got %v", operations)
+	}
+
+	project.Options.Validation = ValidationStrict
+	_, err = LoadProjectOperations(context.Background(), project)
+	if err == nil {
+		t.Fatal("Expected an error in strict mode")
+	}
+	for _, want := range []string{queryPath + ":1: Argument \"readOnly\" of @mcp must be a boolean", queryPath + ":6: Tag @destructive must be true or false",
+		queryPath + ":14: Operation RateFilm is a mutation, it cannot be sent with GET"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got: %v", want, err)
+		}
+	}
+}
+
+func TestLoadOperationsNamespaceCollision(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Project a_b with operation C and project a with operation b_C both become tool a_b_C
+	documents := map[string]string{
+		"a_b": "query C { c }",
+		"a":   "query b_C { c }",
+	}
+	config := &GraphQLConfig{MultiProjects: &GraphQLProjects{Projects: map[string]*GraphQLProject{}}}
+	for name, content := range documents {
+		path := filepath.Join(tempDir, name+".graphql")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create sample GraphQL file: %v", err)
+		}
+		config.MultiProjects.Projects[name] = &GraphQLProject{Documents: []string{path}}
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	operations, err := LoadOperations(context.Background(), config)
+	if err != nil {
+		t.Fatalf("LoadOperations returned an error: %v", err)
+	}
+	if len(operations) != 1 || operations["a_b_C"].Project.Name != "a_b" {
+		t.Fatalf("Expected tool a_b_C of project a_b, got %v", operations)
+	}
+	if want := "tool a_b_C is defined by both b_C in project a and C in project a_b"; !strings.Contains(logs.String(), want) {
+		t.Errorf("Expected the collision to be logged as %q, got: %s", want, logs.String())
+	}
+}
@@ -0,0 +1,33 @@
+package graphql
+
+import "context"
+
+// ProgressFunc receives the progress of a long-running tool call.
+// total is 0 when unknown; progress increases with every call.
+type ProgressFunc func(progress, total float64, message string)
+
+type progressKey struct{}
+
+// WithProgress returns a context that reports the progress of tool calls to fn
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress reports progress to the function set with WithProgress, if any
+func ReportProgress(ctx context.Context, progress, total float64, message string) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		fn(progress, total, message)
+	}
+}
+
+// progressSteps reports the steps of a call as they happen, with progress increasing by one per step
+type progressSteps struct {
+	ctx  context.Context
+	step float64
+}
+
+// report reports the next step, with remaining the number of steps still expected after it
+func (p *progressSteps) report(remaining float64, message string) {
+	ReportProgress(p.ctx, p.step, p.step+remaining, message)
+	p.step++
+}
@@ -0,0 +1,168 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RetryOptions control how calls that fail with a transient error are retried, under `extensions.gqai.retry`.
+// Only idempotent operations are retried: queries unless marked otherwise, and mutations marked idempotent
+// when Mutations is set or the operation opts in with `@mcp(retry: true)`.
+type RetryOptions struct {
+	MaxAttempts    int           `yaml:"maxAttempts"`    // Attempts per call, including the first (default 3, 1 disables retries)
+	InitialBackoff time.Duration `yaml:"initialBackoff"` // Delay before the first retry, doubled for every further one (default 200ms)
+	MaxBackoff     time.Duration `yaml:"maxBackoff"`     // Upper bound of the delay between attempts (default 5s)
+	MaxElapsed     time.Duration `yaml:"maxElapsed"`     // No retry starts after this long, bounded by the call timeout when unset
+	Mutations      bool          `yaml:"mutations"`      // Also retry mutations marked idempotent
+}
+
+const (
+	defaultMaxAttempts    = 3
+	defaultInitialBackoff = 200 * time.Millisecond
+	defaultMaxBackoff     = 5 * time.Second
+)
+
+// retryableStatus are the HTTP statuses of responses worth retrying
+var retryableStatus = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// retryPolicy is the effective retry configuration of an operation
+type retryPolicy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	maxElapsed     time.Duration
+}
+
+// retryPolicy returns how calls of the operation are retried; maxAttempts is 1 when they are not
+func (op *Operation) retryPolicy() retryPolicy {
+	var options RetryOptions
+	if op.Project != nil {
+		options = op.Project.Options.Retry
+	}
+
+	policy := retryPolicy{
+		maxAttempts:    options.MaxAttempts,
+		initialBackoff: durationOr(options.InitialBackoff, defaultInitialBackoff),
+		maxBackoff:     durationOr(options.MaxBackoff, defaultMaxBackoff),
+		maxElapsed:     options.MaxElapsed,
+	}
+	if op.Metadata.MaxAttempts > 0 {
+		policy.maxAttempts = op.Metadata.MaxAttempts
+	}
+	if policy.maxAttempts <= 0 {
+		policy.maxAttempts = defaultMaxAttempts
+	}
+
+	query := op.isQuery()
+	idempotent := query
+	if op.Metadata.Idempotent != nil {
+		idempotent = *op.Metadata.Idempotent
+	}
+	optedIn := query || options.Mutations
+	if op.Metadata.Retry != nil {
+		optedIn = *op.Metadata.Retry
+	}
+	if !idempotent || !optedIn {
+		policy.maxAttempts = 1
+	}
+	return policy
+}
+
+// retryDelay returns how long to wait before the next attempt after a failed one, and whether to retry at all.
+// err is the error of the request, resp its response when one was received.
+func (p retryPolicy) retryDelay(ctx context.Context, attempt int, started time.Time, resp *http.Response, err error) (time.Duration, bool) {
+	if attempt >= p.maxAttempts || ctx.Err() != nil {
+		return 0, false
+	}
+
+	var delay time.Duration
+	switch {
+	case resp != nil:
+		if !retryableStatus[resp.StatusCode] {
+			return 0, false
+		}
+		delay = retryAfter(resp.Header.Get("Retry-After"), time.Now())
+	case !isNetworkError(err):
+		return 0, false
+	}
+
+	if delay <= 0 {
+		delay = p.backoff(attempt)
+	}
+
+	// Give up rather than wait past the total time or the call's deadline
+	if p.maxElapsed > 0 && time.Since(started)+delay > p.maxElapsed {
+		return 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		return 0, false
+	}
+	return delay, true
+}
+
+// isNetworkError reports whether the request failed to reach the endpoint or to get a response,
+// as opposed to being cancelled or rejected
+func isNetworkError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var opErr *net.OpError
+	var urlErr *url.Error
+	return errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		(errors.As(err, &urlErr) && urlErr.Timeout())
+}
+
+// backoff returns the delay before retry number attempt: exponential, capped and jittered
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	delay := p.initialBackoff
+	for i := 1; i < attempt && delay < p.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.maxBackoff {
+		delay = p.maxBackoff
+	}
+	// Spread retries of concurrent calls over the second half of the delay
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as an HTTP date; 0 when absent or invalid
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// sleep waits for the delay, returning early with the context's error when it is done
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
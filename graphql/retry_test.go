@@ -0,0 +1,208 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecuteRetries(t *testing.T) {
+	// The endpoint fails the first two requests with transient errors
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&requests, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"data": {"test": "success"}}`)
+		}
+	}))
+	defer server.Close()
+
+	op := &Operation{
+		Name:          "TestQuery",
+		Query:         "query TestQuery { test }",
+		OperationType: "query",
+		Project:       &GraphQLProject{Options: ProjectOptions{Retry: RetryOptions{InitialBackoff: time.Millisecond}}},
+	}
+
+	var messages []string
+	ctx := WithProgress(context.Background(), func(progress, total float64, message string) {
+		messages = append(messages, fmt.Sprintf("%v/%v %s", progress, total, message))
+	})
+	if _, err := Execute(ctx, server.URL, nil, op, nil); err != nil {
+		t.Fatalf("Execute returned an error: %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests)
+	}
+
+	// Retries are reported as progress, which keeps increasing
+	if len(messages) != 9 || !strings.HasPrefix(messages[2], "2/5 Retrying TestQuery in ") ||
+		!strings.Contains(messages[2], "(attempt 2 of 3): GraphQL error (503)") || messages[8] != "8/8 Done" {
+		t.Errorf("Unexpected progress:\n%s", strings.Join(messages, "\n"))
+	}
+}
+
+func TestExecuteRetryPolicy(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name          string
+		operationType string
+		metadata      ToolMetadata
+		options       RetryOptions
+		status        int
+		wantRequests  int32
+		wantErr       string
+	}{
+		{
+			name:          "query",
+			operationType: "query",
+			status:        http.StatusBadGateway,
+			wantRequests:  3,
+			wantErr:       "GraphQL error (502): unavailable (after 3 attempts)",
+		},
+		{
+			name:          "max attempts of the operation",
+			operationType: "query",
+			metadata:      ToolMetadata{MaxAttempts: 2},
+			options:       RetryOptions{MaxAttempts: 5},
+			status:        http.StatusGatewayTimeout,
+			wantRequests:  2,
+			wantErr:       "GraphQL error (504): unavailable (after 2 attempts)",
+		},
+		{
+			name:          "query opted out",
+			operationType: "query",
+			metadata:      ToolMetadata{Retry: &no},
+			status:        http.StatusServiceUnavailable,
+			wantRequests:  1,
+		},
+		{
+			name:          "non-transient error",
+			operationType: "query",
+			status:        http.StatusBadRequest,
+			wantRequests:  1,
+		},
+		{
+			name:          "mutation",
+			operationType: "mutation",
+			status:        http.StatusServiceUnavailable,
+			wantRequests:  1,
+		},
+		{
+			name:          "idempotent mutation",
+			operationType: "mutation",
+			metadata:      ToolMetadata{Idempotent: &yes},
+			status:        http.StatusServiceUnavailable,
+			wantRequests:  1,
+		},
+		{
+			name:          "idempotent mutation opted in",
+			operationType: "mutation",
+			metadata:      ToolMetadata{Idempotent: &yes, Retry: &yes},
+			status:        http.StatusServiceUnavailable,
+			wantRequests:  3,
+		},
+		{
+			name:          "idempotent mutation with project opt-in",
+			operationType: "mutation",
+			metadata:      ToolMetadata{Idempotent: &yes},
+			options:       RetryOptions{Mutations: true},
+			status:        http.StatusServiceUnavailable,
+			wantRequests:  3,
+		},
+		{
+			name:          "non-idempotent mutation opted in",
+			operationType: "mutation",
+			metadata:      ToolMetadata{Retry: &yes},
+			status:        http.StatusServiceUnavailable,
+			wantRequests:  1,
+		},
+		{
+			name:          "retry after the deadline",
+			operationType: "query",
+			options:       RetryOptions{MaxElapsed: time.Second},
+			status:        http.StatusTooManyRequests,
+			wantRequests:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				if tt.status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "60")
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, "unavailable")
+			}))
+			defer server.Close()
+
+			tt.options.InitialBackoff = time.Millisecond
+			op := &Operation{
+				Name:          "Test",
+				Query:         tt.operationType + " Test { test }",
+				OperationType: tt.operationType,
+				Metadata:      tt.metadata,
+				Project:       &GraphQLProject{Options: ProjectOptions{Retry: tt.options}},
+			}
+			_, err := Execute(context.Background(), server.URL, nil, op, nil)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+			if tt.wantErr != "" && err.Error() != tt.wantErr {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("Expected %d requests, got %d", tt.wantRequests, requests)
+			}
+		})
+	}
+}
+
+func TestExecuteRetriesNetworkErrors(t *testing.T) {
+	// Nothing listens on the endpoint once the server is closed
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := server.URL
+	server.Close()
+
+	op := &Operation{
+		Name:          "TestQuery",
+		Query:         "query TestQuery { test }",
+		OperationType: "query",
+		Project:       &GraphQLProject{Options: ProjectOptions{Retry: RetryOptions{InitialBackoff: time.Millisecond}}},
+	}
+	_, err := Execute(context.Background(), endpoint, nil, op, nil)
+	if err == nil || !strings.Contains(err.Error(), "(after 3 attempts)") {
+		t.Errorf("Expected the request to be retried, got %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"5":                             5 * time.Second,
+		"-1":                            0,
+		"soon":                          0,
+		"Wed, 01 Jan 2025 12:00:30 GMT": 30 * time.Second,
+		"Wed, 01 Jan 2025 11:00:00 GMT": 0,
+	}
+	for value, expected := range tests {
+		if got := retryAfter(value, now); got != expected {
+			t.Errorf("retryAfter(%q) = %s, want %s", value, got, expected)
+		}
+	}
+}
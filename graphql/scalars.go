@@ -0,0 +1,226 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultScalars are the JSON schemas of common custom scalars, used unless `extensions.gqai.scalars` maps them
+var DefaultScalars = map[string]map[string]any{
+	"DateTime":     {"type": "string", "format": "date-time"},
+	"Date":         {"type": "string", "format": "date"},
+	"Time":         {"type": "string", "format": "time"},
+	"UUID":         {"type": "string", "format": "uuid"},
+	"URL":          {"type": "string", "format": "uri"},
+	"URI":          {"type": "string", "format": "uri"},
+	"Email":        {"type": "string", "format": "email"},
+	"EmailAddress": {"type": "string", "format": "email"},
+	"Long":         {"type": "integer"},
+	"BigInt":       {"type": []any{"string", "integer"}, "pattern": "^-?[0-9]+$"},
+	"Decimal":      {"type": []any{"string", "number"}, "pattern": "^-?[0-9]+(\\.[0-9]+)?$"},
+	"JSON":         {},
+	"JSONObject":   {"type": "object"},
+}
+
+// jsonSchemaTypes are the types a scalar can be mapped to
+var jsonSchemaTypes = map[string]bool{
+	"string": true, "number": true, "integer": true, "boolean": true, "object": true, "array": true, "null": true,
+}
+
+// Scalars returns the JSON schema of every custom scalar with a known representation:
+// the built-in DefaultScalars, overridden by the project's `extensions.gqai.scalars`
+func (p *GraphQLProject) Scalars() map[string]map[string]any {
+	scalars := make(map[string]map[string]any, len(DefaultScalars)+len(p.Options.Scalars))
+	for name, schema := range DefaultScalars {
+		scalars[name] = schema
+	}
+	for name, schema := range p.Options.Scalars {
+		scalars[name] = schema
+	}
+	return scalars
+}
+
+// checkScalars makes sure every scalar is mapped to a JSON schema with known types
+func checkScalars(scalars map[string]map[string]any) error {
+	names := make([]string, 0, len(scalars))
+	for name := range scalars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		typ, ok := scalars[name]["type"]
+		if !ok {
+			continue
+		}
+		types, ok := typ.([]any)
+		if !ok {
+			types = []any{typ}
+		}
+		for _, t := range types {
+			if s, ok := t.(string); !ok || !jsonSchemaTypes[s] {
+				return fmt.Errorf("invalid extensions.gqai.scalars.%s: unknown type %v", name, t)
+			}
+		}
+	}
+	for _, name := range names {
+		pattern, ok := scalars[name]["pattern"]
+		if !ok {
+			continue
+		}
+		s, ok := pattern.(string)
+		if !ok {
+			return fmt.Errorf("invalid extensions.gqai.scalars.%s: pattern must be a string", name)
+		}
+		if _, err := regexp.Compile(s); err != nil {
+			return fmt.Errorf("invalid extensions.gqai.scalars.%s: invalid pattern: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// coerceScalar converts a numeric string to a number when the scalar's schema only allows numbers
+func coerceScalar(schema map[string]any, value any) any {
+	s, ok := value.(string)
+	types := schemaTypes(schema)
+	if !ok || len(types) == 0 || types["string"] || !(types["integer"] || types["number"]) {
+		return value
+	}
+	if types["integer"] && !types["number"] {
+		if i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+			return i
+		}
+		return value
+	}
+	if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return f
+	}
+	return value
+}
+
+// scalarViolation checks an argument against the JSON schema of its custom scalar and returns what is wrong
+// with it, or "" when it is valid. The keywords that constrain a single value are checked: type, enum, pattern,
+// minLength, maxLength, minimum and maximum; the others, such as format, only describe it.
+func scalarViolation(schema map[string]any, value any) string {
+	if types := schemaTypes(schema); len(types) > 0 {
+		typ := jsonType(value)
+		if !types[typ] && !(typ == "integer" && types["number"]) {
+			names := make([]string, 0, len(types))
+			for name := range types {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Sprintf("must be of type %s, got %s", strings.Join(names, " or "), describeValue(value))
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			found = found || jsonEqual(allowed, value)
+		}
+		if !found {
+			allowed := make([]string, len(enum))
+			for i, v := range enum {
+				allowed[i] = describeValue(v)
+			}
+			return fmt.Sprintf("must be one of %s, got %s", strings.Join(allowed, ", "), describeValue(value))
+		}
+	}
+
+	if s, ok := value.(string); ok {
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(s) {
+				return fmt.Sprintf("must match %s, got %s", pattern, describeValue(value))
+			}
+		}
+		length := float64(len([]rune(s)))
+		if min, ok := schemaNumber(schema, "minLength"); ok && length < min {
+			return fmt.Sprintf("must be at least %v characters long, got %s", min, describeValue(value))
+		}
+		if max, ok := schemaNumber(schema, "maxLength"); ok && length > max {
+			return fmt.Sprintf("must be at most %v characters long, got %s", max, describeValue(value))
+		}
+	}
+
+	if n, ok := toFloat(value); ok {
+		if min, ok := schemaNumber(schema, "minimum"); ok && n < min {
+			return fmt.Sprintf("must be at least %v, got %s", min, describeValue(value))
+		}
+		if max, ok := schemaNumber(schema, "maximum"); ok && n > max {
+			return fmt.Sprintf("must be at most %v, got %s", max, describeValue(value))
+		}
+	}
+	return ""
+}
+
+// schemaTypes returns the set of types a JSON schema allows, empty when it allows any
+func schemaTypes(schema map[string]any) map[string]bool {
+	types := map[string]bool{}
+	switch t := schema["type"].(type) {
+	case string:
+		types[t] = true
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types[s] = true
+			}
+		}
+	}
+	return types
+}
+
+// jsonType returns the JSON schema type of a decoded JSON value, integer for integral numbers
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	default:
+		if n, ok := toFloat(v); ok && n == math.Trunc(n) {
+			return "integer"
+		}
+		return "number"
+	}
+}
+
+// schemaNumber returns a numeric keyword of a schema, decoded from YAML as an int or a float
+func schemaNumber(schema map[string]any, keyword string) (float64, bool) {
+	return toFloat(schema[keyword])
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// jsonEqual compares values decoded from YAML and JSON, whose numbers have different Go types
+func jsonEqual(a, b any) bool {
+	x, errX := json.Marshal(a)
+	y, errY := json.Marshal(b)
+	if errX != nil || errY != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(x) == string(y)
+}
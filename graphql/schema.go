@@ -0,0 +1,81 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// IsRemote reports whether the schema pointer is an HTTP(S) endpoint rather than a local file
+func (s SchemaPointer) IsRemote() bool {
+	return strings.HasPrefix(s.URL, "http://") || strings.HasPrefix(s.URL, "https://")
+}
+
+// LoadSchema loads the typed schema of a project.
+// Local schema pointers (files or glob patterns) are merged into one schema; `.json` files hold an
+// introspection result and every other file is SDL. Without local pointers,
+// the remote pointers are introspected; an introspection failure is logged and no schema is returned,
+// so that tools keep working without types. Only the first introspection is waited for, until ctx is done
+// or for at most the project's timeout; later calls get the schema in memory while it is refreshed.
+func LoadSchema(ctx context.Context, project *GraphQLProject) (*ast.Schema, error) {
+	var sources []*ast.Source
+	hasRemote := false
+	for _, pointer := range project.Schema {
+		if pointer.IsRemote() {
+			hasRemote = true
+			continue
+		}
+
+		files := []string{pointer.URL}
+		if isGlobPattern(pointer.URL) {
+			matches, err := doublestar.FilepathGlob(pointer.URL, doublestar.WithFilesOnly())
+			if err != nil {
+				return nil, fmt.Errorf("invalid schema pattern %s: %v", pointer.URL, err)
+			}
+			files = matches
+		}
+
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read schema: %v", err)
+			}
+			if filepath.Ext(file) == ".json" {
+				introspection, err := parseIntrospection(data)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read schema %s: %v", file, err)
+				}
+				data = []byte(introspection.toSDL())
+			}
+			sources = append(sources, &ast.Source{Name: file, Input: string(data)})
+		}
+	}
+
+	if len(sources) == 0 {
+		if !hasRemote || project.Options.Introspection.Disabled {
+			return nil, nil
+		}
+		schema, err := loadRemoteSchema(ctx, project)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			return nil, nil
+		}
+		return schema, nil
+	}
+
+	schema, gqlErr := gqlparser.LoadSchema(sources...)
+	if gqlErr != nil {
+		return nil, fmt.Errorf("failed to load schema: %v", gqlErr)
+	}
+	return schema, nil
+}
@@ -0,0 +1,332 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// defaultSchemaTTL is how long a cached schema is used before it is revalidated with the endpoint
+const defaultSchemaTTL = time.Hour
+
+// introspectionTimeout is how long an introspection request may take. Calls waiting for a schema
+// give up sooner, after the project's timeout, while the introspection goes on in the background.
+const introspectionTimeout = 30 * time.Second
+
+// schemaErrorTTL is how long a failed introspection is remembered before it is tried again in the background
+const schemaErrorTTL = 30 * time.Second
+
+// schemaCacheEntry is the on-disk record of an introspected schema
+type schemaCacheEntry struct {
+	URL       string          `json:"url"`
+	ETag      string          `json:"etag,omitempty"`
+	FetchedAt time.Time       `json:"fetchedAt"`
+	Result    json.RawMessage `json:"result"`
+}
+
+// remoteSchemas keeps introspected schemas in memory, so that they are not parsed again on every tool call.
+// The lock only guards the maps: introspection runs outside of it, once per key however many calls wait for it.
+var remoteSchemas = struct {
+	sync.Mutex
+	entries  map[string]remoteSchema
+	fetching map[string]*schemaFetch
+}{entries: map[string]remoteSchema{}, fetching: map[string]*schemaFetch{}}
+
+// schemaFetch is an introspection in flight, its entry is set once done is closed
+type schemaFetch struct {
+	done  chan struct{}
+	entry remoteSchema
+}
+
+type remoteSchema struct {
+	schema  *ast.Schema
+	err     error
+	expires time.Time
+}
+
+// IntrospectSDL introspects the remote schema pointers of a project and returns the merged schema as SDL.
+// With refresh set, the on-disk cache is bypassed and updated with the fresh result.
+func IntrospectSDL(ctx context.Context, project *GraphQLProject, refresh bool) (string, error) {
+	schema, err := introspectProject(ctx, project, refresh)
+	if err != nil {
+		return "", err
+	}
+	return schema.toSDL(), nil
+}
+
+// loadRemoteSchema returns the typed schema of the project's remote schema pointers, from memory when possible.
+// Introspection runs in the background, once per key however many calls need it, so that its result is cached
+// even when no call waits for it anymore. Once loaded, an expired schema or failure is returned right away
+// while it is refreshed, so calls never wait on the endpoint again. Only the first load waits,
+// until ctx is done or for at most the project's timeout.
+func loadRemoteSchema(ctx context.Context, project *GraphQLProject) (*ast.Schema, error) {
+	key := cacheKey(project.Schema)
+
+	remoteSchemas.Lock()
+	entry, loaded := remoteSchemas.entries[key]
+	fetch, fetching := remoteSchemas.fetching[key]
+	if !fetching && (!loaded || !time.Now().Before(entry.expires)) {
+		fetch = &schemaFetch{done: make(chan struct{})}
+		remoteSchemas.fetching[key] = fetch
+		go func() {
+			fetch.entry = fetchRemoteSchema(project)
+
+			remoteSchemas.Lock()
+			remoteSchemas.entries[key] = fetch.entry
+			delete(remoteSchemas.fetching, key)
+			remoteSchemas.Unlock()
+			close(fetch.done)
+		}()
+	}
+	remoteSchemas.Unlock()
+
+	if loaded {
+		return entry.schema, entry.err
+	}
+
+	timeout := time.NewTimer(project.Timeout())
+	defer timeout.Stop()
+
+	select {
+	case <-fetch.done:
+		return fetch.entry.schema, fetch.entry.err
+	case <-timeout.C:
+		return nil, fmt.Errorf("introspection did not complete within %s, continuing without a schema", project.Timeout())
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetchRemoteSchema introspects the project's remote schema pointers; failures are remembered for a shorter time
+func fetchRemoteSchema(project *GraphQLProject) remoteSchema {
+	entry := remoteSchema{expires: time.Now().Add(schemaTTL(project.Options.Introspection))}
+	sdl, err := IntrospectSDL(context.Background(), project, false)
+	if err == nil {
+		schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Name: "introspection.graphql", Input: sdl})
+		if gqlErr != nil {
+			err = fmt.Errorf("failed to load introspected schema: %v", gqlErr)
+		}
+		entry.schema = schema
+	}
+	if err != nil {
+		entry = remoteSchema{err: err, expires: time.Now().Add(schemaErrorTTL)}
+	}
+	return entry
+}
+
+// introspectProject introspects every remote schema pointer of a project and merges the results
+func introspectProject(ctx context.Context, project *GraphQLProject, refresh bool) (*introspectionSchema, error) {
+	client, err := project.HTTPClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+
+	var merged *introspectionSchema
+	for _, pointer := range project.Schema {
+		if !pointer.IsRemote() {
+			continue
+		}
+
+		breaker := endpointBreaker(project, pointer.URL)
+		schema, err := introspectPointer(ctx, client, breaker, pointer, project.Options.Introspection, refresh)
+		if err != nil {
+			return nil, fmt.Errorf("failed to introspect %s: %w", pointer.URL, err)
+		}
+
+		if merged == nil {
+			merged = schema
+		} else {
+			mergeIntrospection(merged, schema)
+		}
+	}
+
+	if merged == nil {
+		return nil, fmt.Errorf("no remote schema to introspect")
+	}
+	return merged, nil
+}
+
+// introspectPointer returns the introspected schema of a single endpoint.
+// A cached result younger than the TTL is used as is; an older one is revalidated with its ETag,
+// and used as a fallback when the endpoint cannot be reached. The request goes through the endpoint's circuit breaker,
+// so the endpoint is not introspected while its circuit is open.
+func introspectPointer(ctx context.Context, client *http.Client, breaker *circuitBreaker, pointer SchemaPointer, options IntrospectionOptions, refresh bool) (*introspectionSchema, error) {
+	cachePath := schemaCachePath(pointer, options)
+
+	var cached *schemaCacheEntry
+	if cachePath != "" {
+		cached = readSchemaCache(cachePath)
+	}
+
+	if cached != nil && !refresh && time.Since(cached.FetchedAt) < schemaTTL(options) {
+		return parseIntrospection(cached.Result)
+	}
+
+	var etag string
+	if cached != nil && !refresh {
+		etag = cached.ETag
+	}
+
+	var body []byte
+	var newETag string
+	var notModified bool
+	err := breaker.allow()
+	if err == nil {
+		body, newETag, notModified, err = fetchIntrospection(ctx, client, pointer, etag)
+		breaker.record(err)
+	}
+	if err != nil {
+		if cached != nil {
+			log.Printf("Warning: using cached schema for %s: %v", pointer.URL, err)
+			return parseIntrospection(cached.Result)
+		}
+		return nil, err
+	}
+
+	entry := &schemaCacheEntry{URL: pointer.URL, ETag: newETag, FetchedAt: time.Now(), Result: body}
+	if notModified {
+		entry.Result = cached.Result
+		if entry.ETag == "" {
+			entry.ETag = cached.ETag
+		}
+	}
+
+	schema, err := parseIntrospection(entry.Result)
+	if err != nil {
+		return nil, err
+	}
+
+	if cachePath != "" {
+		writeSchemaCache(cachePath, entry)
+	}
+	return schema, nil
+}
+
+// fetchIntrospection posts the introspection query to the endpoint.
+// When an ETag is given, it is sent as If-None-Match and a 304 response is reported as notModified.
+func fetchIntrospection(ctx context.Context, client *http.Client, pointer SchemaPointer, etag string) (body []byte, newETag string, notModified bool, err error) {
+	reqBody, err := json.Marshal(graphqlRequest{
+		Query:         IntrospectionQuery,
+		OperationName: "IntrospectionQuery",
+	})
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, introspectionTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", pointer.URL, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range pointer.Headers {
+		req.Header.Set(key, value)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("introspection request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, resp.Header.Get("ETag"), true, nil
+	}
+
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", false, fmt.Errorf("introspection failed: %w", &statusError{StatusCode: resp.StatusCode, Body: string(body)})
+	}
+
+	return body, resp.Header.Get("ETag"), false, nil
+}
+
+func schemaTTL(options IntrospectionOptions) time.Duration {
+	if options.TTL > 0 {
+		return options.TTL
+	}
+	return defaultSchemaTTL
+}
+
+// cacheKey identifies a schema by its URLs and headers, since headers can change what a client is allowed to see
+func cacheKey(pointers []SchemaPointer) string {
+	hash := sha256.New()
+	for _, pointer := range pointers {
+		hash.Write([]byte(pointer.URL))
+		keys := make([]string, 0, len(pointer.Headers))
+		for key := range pointer.Headers {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(hash, "\n%s: %s", key, pointer.Headers[key])
+		}
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// schemaCachePath returns the cache file of a schema pointer, or "" when there is no cache directory
+func schemaCachePath(pointer SchemaPointer, options IntrospectionOptions) string {
+	dir := options.CacheDir
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(userCacheDir, "gqai", "schemas")
+	}
+	return filepath.Join(dir, cacheKey([]SchemaPointer{pointer})+".json")
+}
+
+func readSchemaCache(path string) *schemaCacheEntry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entry schemaCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		log.Printf("Warning: ignoring invalid schema cache %s: %v", path, err)
+		return nil
+	}
+	return &entry
+}
+
+func writeSchemaCache(path string, entry *schemaCacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Warning: failed to encode schema cache: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		log.Printf("Warning: failed to create schema cache directory: %v", err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		log.Printf("Warning: failed to write schema cache: %v", err)
+	}
+}
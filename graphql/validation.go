@@ -0,0 +1,40 @@
+package graphql
+
+import (
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/validator"
+	_ "github.com/vektah/gqlparser/v2/validator/rules" // registers the standard validation rules
+)
+
+// Validation modes, set with `extensions.gqai.validation`
+const (
+	ValidationStrict = "strict" // Refuse to load operations when any of them is invalid
+	ValidationWarn   = "warn"   // Drop invalid operations and log why (default)
+	ValidationOff    = "off"    // Do not validate operations
+)
+
+// validationMode returns the validation mode of the project, defaulting to warn
+func validationMode(project *GraphQLProject) string {
+	if project.Options.Validation == "" {
+		return ValidationWarn
+	}
+	return project.Options.Validation
+}
+
+func checkValidationMode(mode string) error {
+	switch mode {
+	case "", ValidationStrict, ValidationWarn, ValidationOff:
+		return nil
+	default:
+		return fmt.Errorf("invalid extensions.gqai.validation %q: must be %s, %s or %s", mode, ValidationStrict, ValidationWarn, ValidationOff)
+	}
+}
+
+// ValidateOperation checks an operation document against the schema.
+// The errors point to the file, line and column of the offending definitions.
+func ValidateOperation(schema *ast.Schema, doc *ast.QueryDocument) gqlerror.List {
+	return validator.Validate(schema, doc)
+}
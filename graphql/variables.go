@@ -0,0 +1,284 @@
+package graphql
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// maxSafeInteger is the largest integer a JSON number holds exactly in a float64
+const maxSafeInteger = 1 << 53
+
+// VariableError lists the problems found in the arguments of a tool call
+type VariableError struct {
+	Violations []string
+}
+
+func (e *VariableError) Error() string {
+	return "invalid arguments: " + strings.Join(e.Violations, "; ")
+}
+
+// CoerceVariables checks the arguments of a tool call against the operation's variable types,
+// before anything is sent to the backend. Obvious mismatches are coerced (numeric strings for numbers,
+// a single value for a list) and the variables' default values are applied.
+// All the problems found are returned together in a *VariableError.
+func CoerceVariables(op *Operation, input map[string]any) (map[string]any, error) {
+	if op.Doc == nil || len(op.Doc.Operations) == 0 {
+		return input, nil
+	}
+
+	c := &variableCoercer{schema: op.Schema}
+	if op.Project != nil {
+		c.scalars = op.Project.Scalars()
+	}
+	variables := make(map[string]any, len(input))
+	known := make(map[string]bool)
+
+	for _, def := range op.Doc.Operations[0].VariableDefinitions {
+		known[def.Variable] = true
+
+		value, ok := input[def.Variable]
+		if !ok && def.DefaultValue != nil {
+			defaultValue, err := def.DefaultValue.Value(nil)
+			if err == nil {
+				variables[def.Variable] = defaultValue
+			}
+			continue
+		}
+		if !ok {
+			if def.Type.NonNull {
+				c.violation(def.Variable, "is required")
+			}
+			continue
+		}
+
+		variables[def.Variable] = c.coerce(def.Variable, value, def.Type)
+	}
+
+	var unknown []string
+	for name := range input {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		c.violation(name, "is not an argument of this tool")
+	}
+
+	if len(c.violations) > 0 {
+		return nil, &VariableError{Violations: c.violations}
+	}
+	return variables, nil
+}
+
+type variableCoercer struct {
+	schema     *ast.Schema
+	scalars    map[string]map[string]any // JSON schemas of custom scalars, see GraphQLProject.Scalars
+	violations []string
+}
+
+func (c *variableCoercer) violation(path, format string, args ...any) {
+	c.violations = append(c.violations, path+" "+fmt.Sprintf(format, args...))
+}
+
+// coerce returns the value coerced to the GraphQL type, recording a violation when it cannot be
+func (c *variableCoercer) coerce(path string, value any, t *ast.Type) any {
+	if value == nil {
+		if t.NonNull {
+			c.violation(path, "must not be null")
+		}
+		return nil
+	}
+
+	if t.Elem != nil {
+		list, ok := value.([]any)
+		if !ok {
+			// A single value stands for a list of one
+			return []any{c.coerce(path+"[0]", value, t.Elem)}
+		}
+		result := make([]any, len(list))
+		for i, item := range list {
+			result[i] = c.coerce(fmt.Sprintf("%s[%d]", path, i), item, t.Elem)
+		}
+		return result
+	}
+
+	switch t.NamedType {
+	case "Int":
+		return c.coerceInt(path, value)
+	case "Float":
+		return c.coerceFloat(path, value)
+	case "String":
+		if _, ok := value.(string); !ok {
+			c.violation(path, "must be a string, got %s", describeValue(value))
+		}
+		return value
+	case "ID":
+		switch v := value.(type) {
+		case string:
+			return v
+		case float64:
+			if v == math.Trunc(v) && math.Abs(v) <= maxSafeInteger {
+				return strconv.FormatInt(int64(v), 10)
+			}
+		}
+		c.violation(path, "must be a string or an integer ID, got %s", describeValue(value))
+		return value
+	case "Boolean":
+		switch v := value.(type) {
+		case bool:
+			return v
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b
+			}
+		}
+		c.violation(path, "must be a boolean, got %s", describeValue(value))
+		return value
+	}
+
+	var def *ast.Definition
+	if c.schema != nil {
+		def = c.schema.Types[t.NamedType]
+	}
+	if def == nil {
+		// Without the type's definition, the value is left for the backend to check
+		return value
+	}
+
+	switch def.Kind {
+	case ast.Enum:
+		name, ok := value.(string)
+		if !ok || def.EnumValues.ForName(name) == nil {
+			allowed := make([]string, len(def.EnumValues))
+			for i, enumValue := range def.EnumValues {
+				allowed[i] = enumValue.Name
+			}
+			c.violation(path, "must be one of %s, got %s", strings.Join(allowed, ", "), describeValue(value))
+		}
+		return value
+	case ast.InputObject:
+		return c.coerceInputObject(path, value, def)
+	case ast.Scalar:
+		// Custom scalars mapped to a JSON schema are checked against it, the others are left for the backend
+		schema, ok := c.scalars[def.Name]
+		if !ok {
+			return value
+		}
+		value = coerceScalar(schema, value)
+		if problem := scalarViolation(schema, value); problem != "" {
+			c.violation(path, "%s", problem)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+func (c *variableCoercer) coerceInputObject(path string, value any, def *ast.Definition) any {
+	object, ok := value.(map[string]any)
+	if !ok {
+		c.violation(path, "must be an object of type %s, got %s", def.Name, describeValue(value))
+		return value
+	}
+
+	result := make(map[string]any, len(object))
+	for _, field := range def.Fields {
+		fieldValue, ok := object[field.Name]
+		if !ok {
+			if field.Type.NonNull && field.DefaultValue == nil {
+				c.violation(path+"."+field.Name, "is required")
+			}
+			continue
+		}
+		result[field.Name] = c.coerce(path+"."+field.Name, fieldValue, field.Type)
+	}
+
+	var unknown []string
+	for name := range object {
+		if def.Fields.ForName(name) == nil {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		c.violation(path+"."+name, "is not a field of %s", def.Name)
+	}
+	return result
+}
+
+func (c *variableCoercer) coerceInt(path string, value any) any {
+	var n float64
+	switch v := value.(type) {
+	case float64:
+		n = v
+	case int:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 32)
+		if err != nil {
+			c.violation(path, "must be an integer, got %s", describeValue(value))
+			return value
+		}
+		return i
+	default:
+		c.violation(path, "must be an integer, got %s", describeValue(value))
+		return value
+	}
+
+	if n != math.Trunc(n) || n < math.MinInt32 || n > math.MaxInt32 {
+		c.violation(path, "must be a 32-bit integer, got %s", describeValue(value))
+		return value
+	}
+	return int64(n)
+}
+
+// coerceFloat accepts finite numbers only: NaN and infinities have no JSON representation
+func (c *variableCoercer) coerceFloat(path string, value any) any {
+	var f float64
+	switch v := value.(type) {
+	case float64:
+		f = v
+	case int:
+		f = float64(v)
+	case int64:
+		f = float64(v)
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			c.violation(path, "must be a number, got %s", describeValue(value))
+			return value
+		}
+		f = parsed
+	default:
+		c.violation(path, "must be a number, got %s", describeValue(value))
+		return value
+	}
+
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		c.violation(path, "must be a finite number, got %s", describeValue(value))
+		return value
+	}
+	return f
+}
+
+// describeValue formats a JSON value for a violation message
+func describeValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "a list"
+	default:
+		return fmt.Sprint(v)
+	}
+}
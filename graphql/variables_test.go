@@ -0,0 +1,163 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+func TestCoerceVariables(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: `
+type Query {
+  films(filter: FilmFilter, ids: [ID!], limit: Int, rating: Float, episode: Episode, all: Boolean): [String]
+}
+
+input FilmFilter {
+  title: String!
+  episodes: [Episode!]
+  minRating: Float = 0
+}
+
+enum Episode {
+  NEWHOPE
+  EMPIRE
+}
+`})
+	if gqlErr != nil {
+		t.Fatalf("Failed to load test schema: %v", gqlErr)
+	}
+
+	doc, gqlErr := parser.ParseQuery(&ast.Source{Input: `
+query Films($filter: FilmFilter, $ids: [ID!], $limit: Int = 10, $rating: Float, $episode: Episode, $all: Boolean) {
+  films(filter: $filter, ids: $ids, limit: $limit, rating: $rating, episode: $episode, all: $all)
+}`})
+	if gqlErr != nil {
+		t.Fatalf("Failed to parse operation: %v", gqlErr)
+	}
+	op := &Operation{Name: "Films", Doc: doc, Schema: schema}
+
+	// Obvious mismatches are coerced and defaults are applied
+	variables, err := CoerceVariables(op, map[string]any{
+		"filter":  map[string]any{"title": "Hope", "episodes": "NEWHOPE"},
+		"ids":     float64(4),
+		"rating":  "4.5",
+		"episode": "EMPIRE",
+		"all":     "true",
+	})
+	if err != nil {
+		t.Fatalf("CoerceVariables returned an error: %v", err)
+	}
+	expected := map[string]any{
+		"filter":  map[string]any{"title": "Hope", "episodes": []any{"NEWHOPE"}},
+		"ids":     []any{"4"},
+		"limit":   int64(10),
+		"rating":  4.5,
+		"episode": "EMPIRE",
+		"all":     true,
+	}
+	if !reflect.DeepEqual(variables, expected) {
+		got, _ := json.Marshal(variables)
+		t.Errorf("Unexpected variables: %s", got)
+	}
+
+	// Every violation is reported
+	_, err = CoerceVariables(op, map[string]any{
+		"filter":  map[string]any{"episodes": []any{"NEWHOPE", "JEDI"}, "year": 1977},
+		"ids":     []any{"1", nil, 1e20, 4.5},
+		"limit":   "ten",
+		"rating":  "NaN",
+		"episode": 4,
+		"extra":   true,
+	})
+	var variableErr *VariableError
+	if !errors.As(err, &variableErr) {
+		t.Fatalf("Expected a VariableError, got %v", err)
+	}
+	expectedViolations := []string{
+		"filter.title is required",
+		"filter.episodes[1] must be one of NEWHOPE, EMPIRE, got \"JEDI\"",
+		"filter.year is not a field of FilmFilter",
+		"ids[1] must not be null",
+		"ids[2] must be a string or an integer ID, got 1e+20",
+		"ids[3] must be a string or an integer ID, got 4.5",
+		"limit must be an integer, got \"ten\"",
+		"rating must be a finite number, got \"NaN\"",
+		"episode must be one of NEWHOPE, EMPIRE, got 4",
+		"extra is not an argument of this tool",
+	}
+	if !reflect.DeepEqual(variableErr.Violations, expectedViolations) {
+		t.Errorf("Unexpected violations:\n got: %q\nwant: %q", variableErr.Violations, expectedViolations)
+	}
+}
+
+func TestCoerceVariablesCustomScalars(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: `
+scalar Money
+scalar Long
+scalar Stars
+scalar Tier
+scalar Opaque
+
+type Query {
+  price(amount: Money, views: Long, stars: Stars, tier: Tier, opaque: Opaque): String
+}
+`})
+	if gqlErr != nil {
+		t.Fatalf("Failed to load test schema: %v", gqlErr)
+	}
+
+	doc, gqlErr := parser.ParseQuery(&ast.Source{Input: `
+query Price($amount: Money, $views: Long, $stars: Stars, $tier: Tier, $opaque: Opaque) {
+  price(amount: $amount, views: $views, stars: $stars, tier: $tier, opaque: $opaque)
+}`})
+	if gqlErr != nil {
+		t.Fatalf("Failed to parse operation: %v", gqlErr)
+	}
+	op := &Operation{Name: "Price", Doc: doc, Schema: schema, Project: &GraphQLProject{Options: ProjectOptions{
+		Scalars: map[string]map[string]any{
+			"Money": {"type": "string", "pattern": `^[0-9]+\.[0-9]{2} [A-Z]{3}$`},
+			"Stars": {"type": "number", "minimum": 0, "maximum": 5},
+			"Tier":  {"enum": []any{"free", "pro"}},
+		},
+	}}}
+
+	// Valid values pass, numeric strings are coerced for numeric scalars, unmapped scalars are left as is
+	variables, err := CoerceVariables(op, map[string]any{
+		"amount": "12.50 USD",
+		"views":  "42",
+		"stars":  4.5,
+		"tier":   "pro",
+		"opaque": []any{1, "x"},
+	})
+	if err != nil {
+		t.Fatalf("CoerceVariables returned an error: %v", err)
+	}
+	if variables["views"] != int64(42) || variables["amount"] != "12.50 USD" {
+		t.Errorf("Unexpected variables: %v", variables)
+	}
+
+	_, err = CoerceVariables(op, map[string]any{
+		"amount": "12.5",
+		"views":  4.5,
+		"stars":  6,
+		"tier":   "gold",
+	})
+	var variableErr *VariableError
+	if !errors.As(err, &variableErr) {
+		t.Fatalf("Expected a VariableError, got %v", err)
+	}
+	expectedViolations := []string{
+		`amount must match ^[0-9]+\.[0-9]{2} [A-Z]{3}$, got "12.5"`,
+		"views must be of type integer, got 4.5",
+		"stars must be at most 5, got 6",
+		`tier must be one of "free", "pro", got "gold"`,
+	}
+	if !reflect.DeepEqual(variableErr.Violations, expectedViolations) {
+		t.Errorf("Unexpected violations:\n got: %q\nwant: %q", variableErr.Violations, expectedViolations)
+	}
+}
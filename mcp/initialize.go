@@ -7,6 +7,7 @@ const initializeMethod = "initialize"
 var protocolVersions = []string{
 	"2024-11-05",
 	"2025-03-26",
+	"2025-06-18",
 }
 
 func mcpInitialize(request JSONRPCRequest) JSONRPCResponse {
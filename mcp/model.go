@@ -17,6 +17,12 @@ type JSONRPCResponse struct {
 	Error   *JSONRPCError `json:"error,omitempty"`
 }
 
+type JSONRPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
 type JSONRPCError struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
@@ -51,7 +57,9 @@ type Tool struct {
 }
 
 type CallToolResult struct {
-	Content []ToolContent `json:"content"`
+	Content           []ToolContent `json:"content"`
+	StructuredContent interface{}   `json:"structuredContent,omitempty"` // The GraphQL `data`, matching the tool's outputSchema
+	IsError           bool          `json:"isError,omitempty"`           // The tool ran but failed; the content describes the failure
 }
 
 type ToolContent struct {
@@ -59,6 +67,14 @@ type ToolContent struct {
 	Text string `json:"text"`
 }
 
+// Types for notifications
+type ProgressParams struct {
+	ProgressToken interface{} `json:"progressToken"`
+	Progress      float64     `json:"progress"`
+	Total         float64     `json:"total,omitempty"`
+	Message       string      `json:"message,omitempty"`
+}
+
 // Types for resources
 type ListResourcesResult struct {
 	Resources []Resource `json:"resources"`
@@ -1,12 +1,16 @@
 package mcp
 
 import (
+	"context"
 	"fmt"
 	"github.com/fotoetienne/gqai/graphql"
 	"log"
 )
 
-func RouteMCPRequest(request JSONRPCRequest, config *graphql.GraphQLConfig) JSONRPCResponse {
+// RouteMCPRequest handles a JSON-RPC request of the session. Tool calls are aborted when ctx is cancelled,
+// e.g. because the client disconnected, or when the client cancels the request; a cancelled request gets
+// no response.
+func RouteMCPRequest(ctx context.Context, session *Session, request JSONRPCRequest, config *graphql.GraphQLConfig) JSONRPCResponse {
 	switch request.Method {
 
 	case initializeMethod:
@@ -16,11 +20,24 @@ func RouteMCPRequest(request JSONRPCRequest, config *graphql.GraphQLConfig) JSON
 		log.Printf("Server initialized successfully")
 		return JSONRPCResponse{}
 
+	case "ping":
+		return jsonrpcResponse(request, map[string]any{})
+
 	case "tools/list":
-		return ToolsList(request, config)
+		return ToolsList(ctx, request, config)
 
 	case "tools/call":
-		return ToolsCall(request, config)
+		ctx, finish := session.track(ctx, request)
+		response := ToolsCall(session.withProgress(ctx, request), request, config)
+		if finish() {
+			log.Printf("Request %v cancelled by the client", request.ID)
+			return JSONRPCResponse{}
+		}
+		return response
+
+	case "notifications/cancelled":
+		session.cancel(request)
+		return JSONRPCResponse{}
 
 	case "prompts/list":
 		return jsonrpcResponse(request, map[string]any{"prompts": []string{}})
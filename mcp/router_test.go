@@ -1,7 +1,14 @@
 package mcp
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/fotoetienne/gqai/graphql"
 )
@@ -28,7 +35,7 @@ func TestRouteMCPRequest(t *testing.T) {
 		},
 	}
 
-	initResponse := RouteMCPRequest(initRequest, config)
+	initResponse := RouteMCPRequest(context.Background(), nil, initRequest, config)
 	if initResponse.ID != "1" {
 		t.Errorf("Expected response ID to be 1, got %s", initResponse.ID)
 	}
@@ -46,7 +53,7 @@ func TestRouteMCPRequest(t *testing.T) {
 		Method:  "unknown_method",
 	}
 
-	unknownResponse := RouteMCPRequest(unknownRequest, config)
+	unknownResponse := RouteMCPRequest(context.Background(), nil, unknownRequest, config)
 	if unknownResponse.Error == nil {
 		t.Error("Expected error for unknown method, got nil")
 	}
@@ -54,3 +61,124 @@ func TestRouteMCPRequest(t *testing.T) {
 		t.Errorf("Expected error code to be %d, got %d", MethodNotFound, unknownResponse.Error.Code)
 	}
 }
+
+func TestRouteMCPRequestCancelled(t *testing.T) {
+	// The backend hangs until the request is aborted or the test ends
+	received := make(chan struct{})
+	aborted := make(chan struct{})
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		close(received)
+		select {
+		case <-r.Context().Done():
+			close(aborted)
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	config := slowToolConfig(t, server.URL)
+	session := NewSession(func(message any) {})
+
+	responses := make(chan JSONRPCResponse)
+	go func() {
+		responses <- RouteMCPRequest(context.Background(), session, JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "call-1",
+			Method:  "tools/call",
+			Params:  map[string]any{"name": "Slow", "arguments": map[string]any{}},
+		}, config)
+	}()
+
+	<-received
+
+	// Cancelling a request of another session has no effect
+	RouteMCPRequest(context.Background(), NewSession(func(message any) {}), JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  map[string]any{"requestId": "call-1"},
+	}, config)
+
+	cancelResponse := RouteMCPRequest(context.Background(), session, JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  map[string]any{"requestId": "call-1", "reason": "User cancelled"},
+	}, config)
+	if cancelResponse != (JSONRPCResponse{}) {
+		t.Errorf("Expected no response to a notification, got %v", cancelResponse)
+	}
+
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Backend request was not aborted")
+	}
+
+	select {
+	case response := <-responses:
+		if response != (JSONRPCResponse{}) {
+			t.Errorf("Expected no response to a cancelled request, got %+v", response)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Tool call was not cancelled")
+	}
+}
+
+func TestRouteMCPRequestProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"slow": "done"}}`))
+	}))
+	defer server.Close()
+
+	config := slowToolConfig(t, server.URL)
+
+	var notifications []JSONRPCNotification
+	session := NewSession(func(message any) {
+		notifications = append(notifications, message.(JSONRPCNotification))
+	})
+
+	response := RouteMCPRequest(context.Background(), session, JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params: map[string]any{
+			"name":      "Slow",
+			"arguments": map[string]any{},
+			"_meta":     map[string]any{"progressToken": "token-1"},
+		},
+	}, config)
+	if response.Error != nil {
+		t.Fatalf("Expected no error, got %v", response.Error)
+	}
+
+	if len(notifications) == 0 {
+		t.Fatal("Expected progress notifications")
+	}
+	for _, notification := range notifications {
+		params := notification.Params.(ProgressParams)
+		if notification.Method != "notifications/progress" || params.ProgressToken != "token-1" {
+			t.Errorf("Unexpected notification: %+v", notification)
+		}
+	}
+	if last := notifications[len(notifications)-1].Params.(ProgressParams); last.Progress != last.Total {
+		t.Errorf("Expected the last notification to report completion, got %+v", last)
+	}
+}
+
+// slowToolConfig returns a config with a single `Slow` tool, executed against endpoint
+func slowToolConfig(t *testing.T, endpoint string) *graphql.GraphQLConfig {
+	t.Helper()
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "slow.graphql"), []byte("query Slow { slow }"), 0644); err != nil {
+		t.Fatalf("Failed to create sample GraphQL file: %v", err)
+	}
+	return &graphql.GraphQLConfig{
+		SingleProject: &graphql.GraphQLProject{
+			Documents: []string{tempDir},
+			Options:   graphql.ProjectOptions{Endpoint: endpoint},
+		},
+	}
+}
@@ -0,0 +1,138 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/fotoetienne/gqai/graphql"
+)
+
+// Session is the state of one client connection: the requests it has in flight,
+// so that `notifications/cancelled` can abort them, and how to send it notifications.
+// A nil *Session tracks nothing and drops notifications.
+type Session struct {
+	send func(message any)
+
+	mu       sync.Mutex
+	inFlight map[string]*inFlightRequest
+}
+
+type inFlightRequest struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// inFlightKey is the context key of the request a context was tracked for
+type inFlightKey struct{}
+
+// NewSession creates a session that sends notifications to the client with send.
+// send can be called from several goroutines at once.
+func NewSession(send func(message any)) *Session {
+	return &Session{
+		send:     send,
+		inFlight: make(map[string]*inFlightRequest),
+	}
+}
+
+// track registers the request as in flight. The returned context is cancelled when the client
+// cancels the request; finish must be called once the request has been handled and reports
+// whether the client cancelled it, in which case no response must be sent.
+// A request the transport already tracks stays registered until the transport's finish is called.
+func (s *Session) track(ctx context.Context, request JSONRPCRequest) (context.Context, func() bool) {
+	if entry, ok := ctx.Value(inFlightKey{}).(*inFlightRequest); ok && s != nil {
+		return ctx, func() bool {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return entry.cancelled
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	if s == nil {
+		return ctx, func() bool {
+			cancel()
+			return false
+		}
+	}
+
+	key := requestKey(request.ID)
+	entry := &inFlightRequest{cancel: cancel}
+	ctx = context.WithValue(ctx, inFlightKey{}, entry)
+
+	s.mu.Lock()
+	s.inFlight[key] = entry
+	s.mu.Unlock()
+
+	return ctx, func() bool {
+		s.mu.Lock()
+		delete(s.inFlight, key)
+		cancelled := entry.cancelled
+		s.mu.Unlock()
+		cancel()
+		return cancelled
+	}
+}
+
+// cancel handles a `notifications/cancelled` notification
+func (s *Session) cancel(request JSONRPCRequest) {
+	params, _ := request.Params.(map[string]any)
+	if s == nil || params == nil {
+		return
+	}
+	key := requestKey(params["requestId"])
+
+	s.mu.Lock()
+	entry, ok := s.inFlight[key]
+	if ok {
+		entry.cancelled = true
+	}
+	s.mu.Unlock()
+
+	if ok {
+		entry.cancel()
+	}
+}
+
+// withProgress makes the tool execution report progress to the client
+// when the request carries a progress token in `_meta.progressToken`
+func (s *Session) withProgress(ctx context.Context, request JSONRPCRequest) context.Context {
+	params, _ := request.Params.(map[string]any)
+	meta, _ := params["_meta"].(map[string]any)
+	token, ok := meta["progressToken"]
+	if s == nil || !ok || token == nil {
+		return ctx
+	}
+
+	return graphql.WithProgress(ctx, func(progress, total float64, message string) {
+		s.send(JSONRPCNotification{
+			JSONRPC: "2.0",
+			Method:  "notifications/progress",
+			Params: ProgressParams{
+				ProgressToken: token,
+				Progress:      progress,
+				Total:         total,
+				Message:       message,
+			},
+		})
+	})
+}
+
+// requestKey normalizes a JSON-RPC ID, which can be a string or a number
+func requestKey(id any) string {
+	return fmt.Sprintf("%T:%v", id, id)
+}
+
+// withDone returns a context that is also cancelled when done is closed,
+// e.g. when the session a request belongs to ends
+func withDone(ctx context.Context, done <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
@@ -20,10 +20,33 @@ type SSEServer struct {
 // SSEClient represents a connected SSE client
 type SSEClient struct {
 	sessionID string
+	session   *Session
 	writer    http.ResponseWriter
+	writerMux sync.Mutex
+	closed    bool // Set once HandleSSE has returned, the writer must not be used anymore
 	done      chan struct{}
 }
 
+// send writes a message to the client's event stream, dropping it once the stream has ended
+func (c *SSEClient) send(message any) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error marshaling message: %v", err)
+		return
+	}
+
+	c.writerMux.Lock()
+	defer c.writerMux.Unlock()
+	if c.closed {
+		log.Printf("Dropping message for disconnected session %s", c.sessionID)
+		return
+	}
+	fmt.Fprintf(c.writer, "event: message\ndata: %s\n\n", data)
+	if f, ok := c.writer.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 // NewSSEServer creates a new SSE server
 func NewSSEServer(config *graphql.GraphQLConfig) *SSEServer {
 	return &SSEServer{
@@ -50,6 +73,7 @@ func (s *SSEServer) HandleSSE(w http.ResponseWriter, r *http.Request) {
 		writer:    w,
 		done:      make(chan struct{}),
 	}
+	client.session = NewSession(client.send)
 
 	// Register client
 	s.clientsMux.Lock()
@@ -67,6 +91,9 @@ func (s *SSEServer) HandleSSE(w http.ResponseWriter, r *http.Request) {
 		s.clientsMux.Lock()
 		delete(s.clients, sessionID)
 		s.clientsMux.Unlock()
+		client.writerMux.Lock()
+		client.closed = true
+		client.writerMux.Unlock()
 		close(client.done)
 	}()
 
@@ -110,21 +137,14 @@ func (s *SSEServer) HandleMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Route the request
-	response := RouteMCPRequest(request, s.config)
+	// Route the request, aborting it if the client disconnects
+	ctx, cancel := withDone(r.Context(), client.done)
+	defer cancel()
+	response := RouteMCPRequest(ctx, client.session, request, s.config)
 
 	// Send response back via SSE if it's not empty
 	if response != (JSONRPCResponse{}) {
-		responseData, err := json.Marshal(response)
-		if err != nil {
-			log.Printf("Error marshaling response: %v", err)
-			return
-		}
-
-		fmt.Fprintf(client.writer, "event: message\ndata: %s\n\n", responseData)
-		if f, ok := client.writer.(http.Flusher); ok {
-			f.Flush()
-		}
+		client.send(response)
 	}
 
 	w.WriteHeader(http.StatusAccepted)
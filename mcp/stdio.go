@@ -1,32 +1,108 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
-	"github.com/fotoetienne/gqai/graphql"
+	"errors"
+	"io"
 	"log"
 	"os"
+	"strings"
+	"sync"
+
+	"github.com/fotoetienne/gqai/graphql"
 )
 
+// stdioWorkers is the maximum number of requests handled concurrently in stdin/stdout mode
+const stdioWorkers = 8
+
+// stdioQueueSize is how many requests wait for a worker before the reader stops reading
+const stdioQueueSize = 64
+
+// stdioJob is a request waiting for a worker, already tracked so that it can be cancelled while it waits
+type stdioJob struct {
+	ctx     context.Context
+	request JSONRPCRequest
+	finish  func() bool
+}
+
 // RunMCPStdIO starts the MCP server in stdin/stdout mode.
 func RunMCPStdIO(config *graphql.GraphQLConfig) {
 	// Read from stdin and write to stdout
 	// This is a blocking call, so it will not return until the program exits
 
-	decoder := json.NewDecoder(os.Stdin)
-	encoder := json.NewEncoder(os.Stdout)
-
 	// Set up logging to stderr
 	log.SetOutput(os.Stderr)
 	log.SetFlags(log.LstdFlags | log.Lmicroseconds)
 
 	log.Printf("Starting MCP server ...")
 
+	serveStdIO(os.Stdin, os.Stdout, config)
+}
+
+// serveStdIO handles the requests read from in until it is closed.
+// Requests are handled in order by a pool of stdioWorkers, so that a slow tool call does not block
+// `ping`, `tools/list` or its own cancellation; every message is written to out by a single writer.
+// `initialize` is handled before anything else is read, and notifications are handled as soon as they are read.
+// A request cancelled while it waits in the queue is dropped without being handled.
+func serveStdIO(in io.Reader, out io.Writer, config *graphql.GraphQLConfig) {
+	decoder := json.NewDecoder(in)
+	encoder := json.NewEncoder(out)
+
+	// Single writer, so that messages are never interleaved
+	messages := make(chan any, stdioWorkers)
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		for message := range messages {
+			sendResponse(encoder, message)
+		}
+	}()
+
+	session := NewSession(func(message any) {
+		messages <- message
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handle := func(request JSONRPCRequest) {
+		var response = RouteMCPRequest(ctx, session, request, config)
+
+		// Send response if it's not empty
+		if !(response == JSONRPCResponse{}) {
+			//log.Printf("Sending response: %v", PrettyJSON(response))
+			session.send(response)
+		}
+	}
+
+	// At most stdioWorkers requests are handled at once; the others wait in the queue
+	queue := make(chan stdioJob, stdioQueueSize)
+	var workers sync.WaitGroup
+	for i := 0; i < stdioWorkers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range queue {
+				var response JSONRPCResponse
+				if job.ctx.Err() == nil {
+					response = RouteMCPRequest(job.ctx, session, job.request, config)
+				}
+				if cancelled := job.finish(); !cancelled && !(response == JSONRPCResponse{}) {
+					session.send(response)
+				}
+			}
+		}()
+	}
+
 	for {
 		var request JSONRPCRequest
 		if err := decoder.Decode(&request); err != nil {
-			log.Printf("Error decoding request: %v", err)
-			var response = errorResponse(request, ParseError, "Failed to parse JSON")
-			sendResponse(encoder, response)
+			if !errors.Is(err, io.EOF) {
+				log.Printf("Error decoding request: %v", err)
+				var response = errorResponse(request, ParseError, "Failed to parse JSON")
+				session.send(response)
+			}
 			break
 		}
 
@@ -34,19 +110,32 @@ func RunMCPStdIO(config *graphql.GraphQLConfig) {
 
 		if request.JSONRPC != "2.0" {
 			var response = errorResponse(request, InvalidRequest, "Only JSON-RPC 2.0 is supported")
-			sendResponse(encoder, response)
+			session.send(response)
 			continue
 		}
 
-		var response = RouteMCPRequest(request, config)
-
-		// Send response if it's not empty
-		if !(response == JSONRPCResponse{}) {
-			//log.Printf("Sending response: %v", PrettyJSON(response))
-			sendResponse(encoder, response)
+		// The client waits for the initialize response before sending anything else,
+		// and notifications such as cancellations must not queue behind the calls they apply to
+		if request.Method == initializeMethod || isNotification(request) {
+			handle(request)
+			continue
 		}
+
+		// Tracked before it is queued, so that a cancellation read while it waits applies to it
+		requestCtx, finish := session.track(ctx, request)
+		queue <- stdioJob{ctx: requestCtx, request: request, finish: finish}
 	}
 
+	// Finish the requests already read before exiting
+	close(queue)
+	workers.Wait()
+	close(messages)
+	<-writerDone
+}
+
+// isNotification reports whether the message is a notification, which gets no response
+func isNotification(request JSONRPCRequest) bool {
+	return request.ID == nil || strings.HasPrefix(request.Method, "notifications/")
 }
 
 func handleInput(request JSONRPCRequest) {
@@ -0,0 +1,178 @@
+package mcp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServeStdIOConcurrent(t *testing.T) {
+	// The backend hangs until the request is aborted or the test ends
+	received := make(chan struct{})
+	aborted := make(chan struct{})
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		close(received)
+		select {
+		case <-r.Context().Done():
+			close(aborted)
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	config := slowToolConfig(t, server.URL)
+
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	served := make(chan struct{})
+	go func() {
+		serveStdIO(inReader, outWriter, config)
+		outWriter.Close()
+		close(served)
+	}()
+
+	encoder := json.NewEncoder(inWriter)
+	decoder := json.NewDecoder(outReader)
+	send := func(request JSONRPCRequest) {
+		if err := encoder.Encode(request); err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+	}
+	receive := func() JSONRPCResponse {
+		var response JSONRPCResponse
+		if err := decoder.Decode(&response); err != nil {
+			t.Fatalf("Failed to read response: %v", err)
+		}
+		return response
+	}
+
+	send(JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "initialize", Params: map[string]any{"protocolVersion": "2024-11-05"}})
+	if response := receive(); response.ID != float64(1) || response.Error != nil {
+		t.Fatalf("Unexpected initialize response: %+v", response)
+	}
+
+	// A slow tool call does not block the requests read after it
+	send(JSONRPCRequest{JSONRPC: "2.0", ID: 2, Method: "tools/call", Params: map[string]any{"name": "Slow"}})
+	send(JSONRPCRequest{JSONRPC: "2.0", ID: 3, Method: "ping"})
+	if response := receive(); response.ID != float64(3) || response.Error != nil {
+		t.Fatalf("Expected the ping response first, got %+v", response)
+	}
+
+	// The slow call can be cancelled while it is in flight
+	<-received
+	send(JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/cancelled", Params: map[string]any{"requestId": 2}})
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Backend request was not aborted")
+	}
+
+	send(JSONRPCRequest{JSONRPC: "2.0", ID: 4, Method: "tools/list"})
+	if response := receive(); response.ID != float64(4) || response.Error != nil {
+		t.Fatalf("Expected the tools/list response, with none for the cancelled call, got %+v", response)
+	}
+
+	inWriter.Close()
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server did not stop at the end of the input")
+	}
+}
+
+func TestServeStdIOCancelQueued(t *testing.T) {
+	// The backend holds every request until the test releases them
+	var requests int32
+	received := make(chan struct{}, stdioWorkers+1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		atomic.AddInt32(&requests, 1)
+		received <- struct{}{}
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"slow": "done"}}`))
+	}))
+	defer server.Close()
+
+	config := slowToolConfig(t, server.URL)
+	config.Projects() // names the project before the workers read it
+
+	inReader, inWriter := io.Pipe()
+	var output strings.Builder
+	served := make(chan struct{})
+	go func() {
+		serveStdIO(inReader, &lockedWriter{w: &output}, config)
+		close(served)
+	}()
+	requestEncoder := json.NewEncoder(inWriter)
+	send := func(request JSONRPCRequest) {
+		if err := requestEncoder.Encode(request); err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+	}
+
+	// Every worker is busy with a call, the next one waits in the queue
+	for id := 1; id <= stdioWorkers+1; id++ {
+		send(JSONRPCRequest{JSONRPC: "2.0", ID: id, Method: "tools/call", Params: map[string]any{"name": "Slow"}})
+	}
+	for i := 0; i < stdioWorkers; i++ {
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Backend did not receive the calls")
+		}
+	}
+
+	// The queued call is cancelled before a worker picks it up
+	send(JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/cancelled", Params: map[string]any{"requestId": stdioWorkers + 1}})
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	inWriter.Close()
+
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server did not stop at the end of the input")
+	}
+
+	if n := atomic.LoadInt32(&requests); n != stdioWorkers {
+		t.Errorf("Expected the queued call not to reach the backend, got %d calls", n)
+	}
+	decoder := json.NewDecoder(strings.NewReader(output.String()))
+	responses := 0
+	for decoder.More() {
+		var response JSONRPCResponse
+		if err := decoder.Decode(&response); err != nil {
+			t.Fatalf("Failed to read response: %v", err)
+		}
+		if response.ID == float64(stdioWorkers+1) {
+			t.Errorf("Expected no response for the cancelled call, got %+v", response)
+		}
+		responses++
+	}
+	if responses != stdioWorkers {
+		t.Errorf("Expected a response for each call handled, got %d", responses)
+	}
+}
+
+// lockedWriter serializes writes, so that the output can be read once the server is done
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
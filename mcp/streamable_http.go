@@ -12,16 +12,43 @@ import (
 
 // StreamableHTTPServer represents a streamable HTTP server instance
 type StreamableHTTPServer struct {
-	config     *graphql.GraphQLConfig
-	sessions   map[string]*StreamableHTTPSession
+	config      *graphql.GraphQLConfig
+	sessions    map[string]*StreamableHTTPSession
 	sessionsMux sync.RWMutex
 }
 
 // StreamableHTTPSession represents a session for streamable HTTP
 type StreamableHTTPSession struct {
 	sessionID string
-	responses chan JSONRPCResponse
-	done      chan struct{}
+	session   *Session
+	responses chan any      // Responses and notifications, streamed to the client; never closed
+	done      chan struct{} // Closed when the session ends
+	closeOnce sync.Once
+}
+
+// send queues a message for the client's stream. Notifications are dropped when the stream is not keeping up,
+// responses wait for room in it; both are dropped once the session has ended.
+func (s *StreamableHTTPSession) send(message any) {
+	if _, ok := message.(JSONRPCNotification); ok {
+		select {
+		case s.responses <- message:
+		case <-s.done:
+		default:
+			log.Printf("Dropping notification for session %s, its stream is not keeping up", s.sessionID)
+		}
+		return
+	}
+
+	select {
+	case s.responses <- message:
+	case <-s.done:
+		log.Printf("Dropping response for ended session %s", s.sessionID)
+	}
+}
+
+// close ends the session, it can be called more than once
+func (s *StreamableHTTPSession) close() {
+	s.closeOnce.Do(func() { close(s.done) })
 }
 
 // NewStreamableHTTPServer creates a new streamable HTTP server
@@ -61,9 +88,10 @@ func (s *StreamableHTTPServer) handleStreamableHTTPGet(w http.ResponseWriter, r
 	// Create session
 	session := &StreamableHTTPSession{
 		sessionID: sessionID,
-		responses: make(chan JSONRPCResponse, 10),
+		responses: make(chan any, 10),
 		done:      make(chan struct{}),
 	}
+	session.session = NewSession(session.send)
 
 	// Register session
 	s.sessionsMux.Lock()
@@ -81,15 +109,14 @@ func (s *StreamableHTTPServer) handleStreamableHTTPGet(w http.ResponseWriter, r
 		s.sessionsMux.Lock()
 		delete(s.sessions, sessionID)
 		s.sessionsMux.Unlock()
-		close(session.done)
-		close(session.responses)
+		session.close()
 	}()
 
 	// Stream responses
 	for {
 		select {
 		case response := <-session.responses:
-			if response != (JSONRPCResponse{}) {
+			if response != nil {
 				responseData, err := json.Marshal(response)
 				if err != nil {
 					log.Printf("Error marshaling response: %v", err)
@@ -132,14 +159,14 @@ func (s *StreamableHTTPServer) handleStreamableHTTPPost(w http.ResponseWriter, r
 		return
 	}
 
-	// Route the request
-	response := RouteMCPRequest(request, s.config)
+	// Route the request, aborting it if the client disconnects
+	ctx, cancel := withDone(r.Context(), session.done)
+	defer cancel()
+	response := RouteMCPRequest(ctx, session.session, request, s.config)
 
-	// Send response to session channel
-	select {
-	case session.responses <- response:
-	default:
-		log.Printf("Response channel full for session %s", sessionID)
+	// Send response to session channel if it's not empty
+	if response != (JSONRPCResponse{}) {
+		session.send(response)
 	}
 
 	w.WriteHeader(http.StatusAccepted)
@@ -155,7 +182,7 @@ func (s *StreamableHTTPServer) handleStreamableHTTPDelete(w http.ResponseWriter,
 
 	s.sessionsMux.Lock()
 	if session, exists := s.sessions[sessionID]; exists {
-		close(session.done)
+		session.close()
 		delete(s.sessions, sessionID)
 	}
 	s.sessionsMux.Unlock()
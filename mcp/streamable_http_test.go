@@ -0,0 +1,52 @@
+package mcp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStreamableHTTPSessionSend(t *testing.T) {
+	session := &StreamableHTTPSession{
+		sessionID: "http_test",
+		responses: make(chan any, 2),
+		done:      make(chan struct{}),
+	}
+
+	// Progress beyond what the stream can hold is dropped
+	for i := 0; i < 3; i++ {
+		session.send(JSONRPCNotification{JSONRPC: "2.0", Method: "notifications/progress"})
+	}
+	if len(session.responses) != 2 {
+		t.Fatalf("Expected the stream to hold 2 notifications, got %d", len(session.responses))
+	}
+
+	// A response waits for room in the stream rather than being dropped
+	sent := make(chan struct{})
+	go func() {
+		session.send(JSONRPCResponse{JSONRPC: "2.0", ID: 1})
+		close(sent)
+	}()
+	select {
+	case <-sent:
+		t.Fatal("Expected the response to wait for room in the stream")
+	case <-time.After(20 * time.Millisecond):
+	}
+	<-session.responses
+	<-session.responses
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Response was not queued")
+	}
+	if response, ok := (<-session.responses).(JSONRPCResponse); !ok || response.ID != 1 {
+		t.Errorf("Expected the response to be streamed, got %v", response)
+	}
+
+	// Once the session has ended, messages are dropped instead of blocking or panicking
+	session.close()
+	session.close()
+	session.send(JSONRPCNotification{JSONRPC: "2.0", Method: "notifications/progress"})
+	session.send(JSONRPCResponse{JSONRPC: "2.0", ID: 2})
+	session.send(JSONRPCResponse{JSONRPC: "2.0", ID: 3})
+	session.send(JSONRPCResponse{JSONRPC: "2.0", ID: 4})
+}
@@ -1,13 +1,19 @@
 package mcp
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+
 	"github.com/fotoetienne/gqai/graphql"
 	"github.com/fotoetienne/gqai/tool"
 )
 
 // ToolsCall handles the 'tools/call' MCP command.
-func ToolsCall(request JSONRPCRequest, config *graphql.GraphQLConfig) JSONRPCResponse {
+// Problems with the request itself are JSON-RPC errors; failures of the tool, including GraphQL errors,
+// are reported in a CallToolResult with isError set, so that the model can see them.
+func ToolsCall(ctx context.Context, request JSONRPCRequest, config *graphql.GraphQLConfig) JSONRPCResponse {
 	// Check if the tool name is provided in the request
 	if request.Params == nil {
 		return errorResponse(request, InvalidParams, "Params must include tool name")
@@ -18,16 +24,36 @@ func ToolsCall(request JSONRPCRequest, config *graphql.GraphQLConfig) JSONRPCRes
 	}
 
 	// Load tool
-	tool, err := tool.LoadTool(config, toolName)
+	mcpTool, err := tool.LoadTool(ctx, config, toolName)
+	if errors.Is(err, tool.ErrToolNotFound) {
+		return errorResponse(request, InvalidParams, fmt.Sprintf("Unknown tool: %s", toolName))
+	}
 	if err != nil {
 		return errorResponse(request, InternalError, err.Error())
 	}
 
 	// Execute the tool with the provided input
-	input, ok := request.Params.(map[string]any)["arguments"].(map[string]any)
-	resp, err := tool.Execute(input)
+	input := map[string]any{}
+	if arguments, exists := request.Params.(map[string]any)["arguments"]; exists && arguments != nil {
+		if input, ok = arguments.(map[string]any); !ok {
+			return errorResponse(request, InvalidParams, "Tool arguments must be an object")
+		}
+	}
+	resp, err := mcpTool.Execute(ctx, input)
+	var variableErr *graphql.VariableError
+	if errors.As(err, &variableErr) {
+		return JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &JSONRPCError{
+				Code:    InvalidParams,
+				Message: fmt.Sprintf("Invalid arguments for tool %v: %s", toolName, strings.Join(variableErr.Violations, "; ")),
+				Data:    map[string]any{"violations": variableErr.Violations},
+			},
+		}
+	}
 	if err != nil {
-		return errorResponse(request, InternalError, fmt.Sprintf("Error executing tool %v: %v", toolName, err))
+		return jsonrpcResponse(request, toolError(fmt.Sprintf("Error executing tool %v: %v", toolName, err)))
 	}
 
 	// Return the result wrapped in MCP response format
@@ -37,7 +63,7 @@ func ToolsCall(request JSONRPCRequest, config *graphql.GraphQLConfig) JSONRPCRes
 
 	// Check if the response is a valid JSON object
 	if _, ok := resp.(map[string]any); !ok {
-		return errorResponse(request, InternalError, fmt.Sprintf("Tool %v returned an invalid response", toolName))
+		return jsonrpcResponse(request, toolError(fmt.Sprintf("Tool %v returned an invalid response", toolName)))
 	}
 
 	// Convert the json response to a json-escaped string
@@ -55,10 +81,29 @@ func ToolsCall(request JSONRPCRequest, config *graphql.GraphQLConfig) JSONRPCRes
 		},
 	}
 
-	return JSONRPCResponse{
-		JSONRPC: "2.0",
-		ID:      request.ID,
-		Result:  result,
-		Error:   nil,
+	// Tools with an output schema also return the data as structured content
+	if data, ok := resp.(map[string]any)["data"].(map[string]any); ok && mcpTool.OutputSchema != nil {
+		result.StructuredContent = data
+	}
+
+	// GraphQL errors fail the call when there is no data; with partial data, the data is returned
+	// and the errors are listed after it
+	if errs, hasData := graphql.ResponseErrors(resp); len(errs) > 0 {
+		text := "GraphQL errors:\n- " + strings.Join(errs, "\n- ")
+		if hasData {
+			result.Content = append(result.Content, ToolContent{Type: "text", Text: text})
+		} else {
+			result = toolError(text)
+		}
+	}
+
+	return jsonrpcResponse(request, result)
+}
+
+// toolError is the result of a tool call that failed
+func toolError(message string) CallToolResult {
+	return CallToolResult{
+		Content: []ToolContent{{Type: "text", Text: message}},
+		IsError: true,
 	}
 }
@@ -0,0 +1,161 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fotoetienne/gqai/graphql"
+)
+
+func TestToolsCallErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantIsError bool
+		wantTexts   []string
+	}{
+		{
+			name:      "data",
+			status:    http.StatusOK,
+			body:      `{"data": {"slow": "done"}}`,
+			wantTexts: []string{`{"data":{"slow":"done"}}`},
+		},
+		{
+			name:        "errors without data",
+			status:      http.StatusOK,
+			body:        `{"data": null, "errors": [{"message": "Not allowed", "path": ["slow"], "extensions": {"code": "FORBIDDEN"}}]}`,
+			wantIsError: true,
+			wantTexts:   []string{"GraphQL errors:\n- Not allowed (path: slow, code: FORBIDDEN)"},
+		},
+		{
+			name:   "partial data",
+			status: http.StatusOK,
+			body:   `{"data": {"slow": null, "other": 1}, "errors": [{"message": "Timed out", "path": ["slow", 0]}]}`,
+			wantTexts: []string{
+				`{"data":{"other":1,"slow":null},"errors":[{"message":"Timed out","path":["slow",0]}]}`,
+				"GraphQL errors:\n- Timed out (path: slow.0)",
+			},
+		},
+		{
+			name:        "server error",
+			status:      http.StatusInternalServerError,
+			body:        `internal error`,
+			wantIsError: true,
+			wantTexts:   []string{"Error executing tool Slow: GraphQL error (500): internal error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			response := ToolsCall(context.Background(), JSONRPCRequest{
+				JSONRPC: "2.0",
+				ID:      1,
+				Method:  "tools/call",
+				Params:  map[string]any{"name": "Slow", "arguments": map[string]any{}},
+			}, slowToolConfig(t, server.URL))
+
+			// Tool failures are results, not JSON-RPC errors
+			if response.Error != nil {
+				t.Fatalf("Expected no JSON-RPC error, got %v", response.Error)
+			}
+			result := response.Result.(CallToolResult)
+			if result.IsError != tt.wantIsError {
+				t.Errorf("Expected isError %v, got %v", tt.wantIsError, result.IsError)
+			}
+			var texts []string
+			for _, content := range result.Content {
+				texts = append(texts, content.Text)
+			}
+			if strings.Join(texts, "\n---\n") != strings.Join(tt.wantTexts, "\n---\n") {
+				t.Errorf("Unexpected content:\n got: %q\nwant: %q", texts, tt.wantTexts)
+			}
+		})
+	}
+}
+
+func TestToolsCallStructuredContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"slow": "done"}}`))
+	}))
+	defer server.Close()
+
+	config := slowToolConfig(t, server.URL)
+	schemaPath := filepath.Join(t.TempDir(), "schema.graphql")
+	if err := os.WriteFile(schemaPath, []byte("type Query { slow: String }"), 0644); err != nil {
+		t.Fatalf("Failed to create schema file: %v", err)
+	}
+	config.SingleProject.Schema = []graphql.SchemaPointer{{URL: schemaPath}}
+
+	response := ToolsCall(context.Background(), JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params:  map[string]any{"name": "Slow", "arguments": map[string]any{}},
+	}, config)
+	if response.Error != nil {
+		t.Fatalf("Expected no JSON-RPC error, got %v", response.Error)
+	}
+
+	result := response.Result.(CallToolResult)
+	data, ok := result.StructuredContent.(map[string]any)
+	if !ok || data["slow"] != "done" {
+		t.Errorf("Expected the data as structured content, got %v", result.StructuredContent)
+	}
+	if len(result.Content) != 1 || result.Content[0].Text != `{"data":{"slow":"done"}}` {
+		t.Errorf("Expected the text content to be kept, got %v", result.Content)
+	}
+}
+
+func TestToolsCallInvalidArguments(t *testing.T) {
+	// The backend must not be called with invalid arguments
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected request to the backend")
+	}))
+	defer server.Close()
+
+	response := ToolsCall(context.Background(), JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params:  map[string]any{"name": "Slow", "arguments": map[string]any{"limit": 5}},
+	}, slowToolConfig(t, server.URL))
+
+	if response.Error == nil || response.Error.Code != InvalidParams {
+		t.Fatalf("Expected an invalid params error, got %+v", response)
+	}
+	if response.Error.Message != "Invalid arguments for tool Slow: limit is not an argument of this tool" {
+		t.Errorf("Unexpected error message: %s", response.Error.Message)
+	}
+	data, _ := response.Error.Data.(map[string]any)
+	if violations, _ := data["violations"].([]string); len(violations) != 1 {
+		t.Errorf("Expected the violations in the error data, got %v", response.Error.Data)
+	}
+}
+
+func TestToolsCallUnknownTool(t *testing.T) {
+	response := ToolsCall(context.Background(), JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params:  map[string]any{"name": "Missing"},
+	}, slowToolConfig(t, "http://localhost:0"))
+
+	// An unknown tool is the client's mistake
+	if response.Error == nil || response.Error.Code != InvalidParams || response.Error.Message != "Unknown tool: Missing" {
+		t.Errorf("Expected an invalid params error, got %+v", response.Error)
+	}
+}
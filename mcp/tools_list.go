@@ -1,14 +1,15 @@
 package mcp
 
 import (
+	"context"
 	"fmt"
 	"github.com/fotoetienne/gqai/graphql"
 	"github.com/fotoetienne/gqai/tool"
 )
 
 // ToolsList handles the 'tools/list' MCP command.
-func ToolsList(request JSONRPCRequest, config *graphql.GraphQLConfig) JSONRPCResponse {
-	tools, err := tool.ToolsFromConfig(config)
+func ToolsList(ctx context.Context, request JSONRPCRequest, config *graphql.GraphQLConfig) JSONRPCResponse {
+	tools, err := tool.ToolsFromConfig(ctx, config)
 	if err != nil {
 		return errorResponse(request, InternalError, fmt.Sprintf("Error loading tools: %v", err))
 	}
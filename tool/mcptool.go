@@ -1,11 +1,14 @@
 package tool
 
+import "context"
+
 type MCPTool struct {
-	Name        string                                  `json:"name"`
-	Description string                                  `json:"description"`
-	InputSchema map[string]interface{}                  `json:"inputSchema"`
-	Execute     func(input map[string]any) (any, error) `json:"-"`
-	Annotations struct {
+	Name         string                                                       `json:"name"`
+	Description  string                                                       `json:"description"`
+	InputSchema  map[string]interface{}                                       `json:"inputSchema"`
+	OutputSchema map[string]interface{}                                       `json:"outputSchema,omitempty"` // Schema of the GraphQL `data`, nil without a schema
+	Execute      func(ctx context.Context, input map[string]any) (any, error) `json:"-"`
+	Annotations  struct {
 		Title           string `json:"title,omitempty"`
 		ReadOnlyHint    bool   `json:"readOnlyHint"`
 		DestructiveHint bool   `json:"destructiveHint"`
@@ -0,0 +1,196 @@
+package tool
+
+import (
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// ExtractOutputSchema builds the JSON schema of the `data` object returned by the operation,
+// from its selection set and the schema. Custom scalars use their JSON schema from scalars.
+// Without a schema the result types are unknown, and nil is returned.
+func ExtractOutputSchema(rawQuery string, schema *ast.Schema, scalars map[string]map[string]any) (map[string]any, error) {
+	if schema == nil {
+		return nil, nil
+	}
+
+	doc, err := parser.ParseQuery(&ast.Source{Input: rawQuery})
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Operations) == 0 {
+		return nil, nil
+	}
+
+	op := doc.Operations[0]
+	var root *ast.Definition
+	switch op.Operation {
+	case ast.Query:
+		root = schema.Query
+	case ast.Mutation:
+		root = schema.Mutation
+	case ast.Subscription:
+		root = schema.Subscription
+	}
+	if root == nil {
+		return nil, nil
+	}
+
+	builder := &outputSchemaBuilder{schema: schema, doc: doc, scalars: scalars}
+	return builder.selectionSetSchema(root, op.SelectionSet), nil
+}
+
+// outputSchemaBuilder converts the selection sets of an operation to JSON schemas
+type outputSchemaBuilder struct {
+	schema  *ast.Schema
+	doc     *ast.QueryDocument
+	scalars map[string]map[string]any
+}
+
+// selectionSetSchema returns the object schema of the fields selected on a type.
+// Fields are required unless they are conditional: behind @skip/@include or a fragment on a narrower type.
+func (b *outputSchemaBuilder) selectionSetSchema(def *ast.Definition, selectionSet ast.SelectionSet) map[string]any {
+	result := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{},
+	}
+	b.collectFields(result, def, selectionSet, true, map[string]bool{})
+	return result
+}
+
+func (b *outputSchemaBuilder) collectFields(result map[string]any, def *ast.Definition, selectionSet ast.SelectionSet, always bool, fragments map[string]bool) {
+	props := result["properties"].(map[string]any)
+
+	for _, selection := range selectionSet {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			key := sel.Alias
+			if key == "" {
+				key = sel.Name
+			}
+
+			var fieldSchema map[string]any
+			if sel.Name == "__typename" {
+				fieldSchema = map[string]any{"type": "string"}
+			} else if fieldDef := def.Fields.ForName(sel.Name); fieldDef != nil {
+				fieldSchema = b.typeSchema(fieldDef.Type, sel.SelectionSet)
+				if fieldDef.Description != "" {
+					fieldSchema["description"] = fieldDef.Description
+				}
+			} else {
+				fieldSchema = map[string]any{}
+			}
+
+			if existing, ok := props[key].(map[string]any); ok {
+				mergeSchemas(existing, fieldSchema)
+			} else {
+				props[key] = fieldSchema
+			}
+			if always && len(sel.Directives) == 0 {
+				addRequired(result, key)
+			}
+
+		case *ast.InlineFragment:
+			target := def
+			if sel.TypeCondition != "" {
+				target = b.schema.Types[sel.TypeCondition]
+			}
+			if target == nil {
+				continue
+			}
+			b.collectFields(result, target, sel.SelectionSet, always && target == def && len(sel.Directives) == 0, fragments)
+
+		case *ast.FragmentSpread:
+			fragment := b.doc.Fragments.ForName(sel.Name)
+			if fragment == nil || fragments[sel.Name] {
+				continue
+			}
+			target := b.schema.Types[fragment.TypeCondition]
+			if target == nil {
+				continue
+			}
+			fragments[sel.Name] = true
+			b.collectFields(result, target, fragment.SelectionSet, always && target == def && len(sel.Directives) == 0, fragments)
+			delete(fragments, sel.Name)
+		}
+	}
+}
+
+// typeSchema returns the JSON schema of a field's value; nullable types also accept null
+func (b *outputSchemaBuilder) typeSchema(t *ast.Type, selectionSet ast.SelectionSet) map[string]any {
+	var result map[string]any
+	if t.Elem != nil {
+		result = map[string]any{
+			"type":  "array",
+			"items": b.typeSchema(t.Elem, selectionSet),
+		}
+	} else {
+		def := b.schema.Types[t.NamedType]
+		switch {
+		case def == nil:
+			result = map[string]any{}
+		case def.Kind == ast.Object || def.Kind == ast.Interface || def.Kind == ast.Union:
+			result = b.selectionSetSchema(def, selectionSet)
+		case def.Kind == ast.Enum:
+			values := make([]any, 0, len(def.EnumValues))
+			for _, value := range def.EnumValues {
+				values = append(values, value.Name)
+			}
+			result = map[string]any{"type": "string", "enum": values}
+		case def.BuiltIn:
+			result = map[string]any{"type": graphqlTypeToJSONSchemaType(t)}
+		default:
+			// Custom scalars can be serialized as anything, unless they are mapped
+			var ok bool
+			if result, ok = scalarSchema(b.scalars, t.NamedType); !ok {
+				result = map[string]any{}
+			}
+		}
+	}
+
+	if !t.NonNull {
+		result = nullable(result)
+	}
+	return result
+}
+
+// mergeSchemas adds the properties of src to dst, for a field selected more than once,
+// e.g. directly and through a fragment
+func mergeSchemas(dst, src map[string]any) {
+	if dstItems, ok := dst["items"].(map[string]any); ok {
+		if srcItems, ok := src["items"].(map[string]any); ok {
+			mergeSchemas(dstItems, srcItems)
+		}
+		return
+	}
+
+	dstProps, ok := dst["properties"].(map[string]any)
+	if !ok {
+		return
+	}
+	srcProps, ok := src["properties"].(map[string]any)
+	if !ok {
+		return
+	}
+	for key, value := range srcProps {
+		if existing, ok := dstProps[key].(map[string]any); ok {
+			mergeSchemas(existing, value.(map[string]any))
+		} else {
+			dstProps[key] = value
+		}
+	}
+	if required, ok := src["required"].([]string); ok {
+		for _, key := range required {
+			addRequired(dst, key)
+		}
+	}
+}
+
+func addRequired(schema map[string]any, key string) {
+	required, _ := schema["required"].([]string)
+	for _, existing := range required {
+		if existing == key {
+			return
+		}
+	}
+	schema["required"] = append(required, key)
+}
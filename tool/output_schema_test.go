@@ -0,0 +1,117 @@
+package tool
+
+import (
+	"testing"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const outputTestSchemaSDL = `
+scalar DateTime
+
+type Query {
+  film(id: ID!): Film
+  search(text: String!): [SearchResult!]!
+}
+
+interface Node {
+  id: ID!
+}
+
+type Film implements Node {
+  id: ID!
+  "The title of the film"
+  title: String!
+  episode: Episode
+  releasedAt: DateTime
+  characters: [Person]
+}
+
+type Person implements Node {
+  id: ID!
+  name: String
+  height: Int
+}
+
+union SearchResult = Film | Person
+
+enum Episode {
+  NEWHOPE
+  EMPIRE
+}
+`
+
+func TestExtractOutputSchema(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: outputTestSchemaSDL})
+	if gqlErr != nil {
+		t.Fatalf("Failed to load test schema: %v", gqlErr)
+	}
+
+	outputSchema, err := ExtractOutputSchema(`
+query Film($id: ID!, $withCharacters: Boolean!) {
+  movie: film(id: $id) {
+    ...FilmFields
+    episode
+    releasedAt
+    characters @include(if: $withCharacters) { name }
+  }
+}
+
+fragment FilmFields on Film {
+  id
+  title
+  characters { height }
+}`, schema, nil)
+	if err != nil {
+		t.Fatalf("ExtractOutputSchema returned an error: %v", err)
+	}
+
+	// Aliases are used as keys, nullable fields accept null, fields selected twice are merged
+	// and conditional fields are optional
+	expected := `{"properties":{"movie":{"properties":{` +
+		`"characters":{"items":{"properties":{"height":{"type":["integer","null"]},"name":{"type":["string","null"]}},` +
+		`"required":["height","name"],"type":["object","null"]},"type":["array","null"]},` +
+		`"episode":{"enum":["NEWHOPE","EMPIRE",null],"type":["string","null"]},` +
+		`"id":{"type":"string"},` +
+		`"releasedAt":{},` +
+		`"title":{"description":"The title of the film","type":"string"}},` +
+		`"required":["id","title","characters","episode","releasedAt"],"type":["object","null"]}},` +
+		`"required":["movie"],"type":"object"}`
+	if got := toJSON(t, outputSchema); got != expected {
+		t.Errorf("Unexpected output schema:\n got: %s\nwant: %s", got, expected)
+	}
+}
+
+func TestExtractOutputSchemaAbstractTypes(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: outputTestSchemaSDL})
+	if gqlErr != nil {
+		t.Fatalf("Failed to load test schema: %v", gqlErr)
+	}
+
+	outputSchema, err := ExtractOutputSchema(`
+query Search($text: String!) {
+  search(text: $text) {
+    __typename
+    ... on Node { id }
+    ... on Film { title }
+    ... on Person { name }
+  }
+}`, schema, nil)
+	if err != nil {
+		t.Fatalf("ExtractOutputSchema returned an error: %v", err)
+	}
+
+	// Only __typename is selected on every member of the union
+	expected := `{"properties":{"search":{"items":{"properties":{` +
+		`"__typename":{"type":"string"},"id":{"type":"string"},"name":{"type":["string","null"]},` +
+		`"title":{"description":"The title of the film","type":"string"}},` +
+		`"required":["__typename"],"type":"object"},"type":"array"}},"required":["search"],"type":"object"}`
+	if got := toJSON(t, outputSchema); got != expected {
+		t.Errorf("Unexpected output schema:\n got: %s\nwant: %s", got, expected)
+	}
+
+	if outputSchema, _ := ExtractOutputSchema(`query Search { search(text: "x") { __typename } }`, nil, nil); outputSchema != nil {
+		t.Errorf("Expected no output schema without a schema, got %v", outputSchema)
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"github.com/vektah/gqlparser/v2/parser"
 )
 
-func ExtractInputSchema(rawQuery string) (map[string]any, error) {
+// ExtractInputSchema builds the JSON schema of the tool input from the operation's variables.
+// When a schema is given, input objects, enums and descriptions are taken from it.
+// Custom scalars use their JSON schema from scalars; any other non-builtin type falls back to a string.
+func ExtractInputSchema(rawQuery string, schema *ast.Schema, scalars map[string]map[string]any) (map[string]any, error) {
 	doc, err := parser.ParseQuery(&ast.Source{Input: rawQuery})
 	if err != nil {
 		return nil, err
@@ -16,16 +19,21 @@ func ExtractInputSchema(rawQuery string) (map[string]any, error) {
 	}
 
 	op := doc.Operations[0]
+	builder := newSchemaBuilder(schema, scalars)
+	descriptions := variableDescriptions(schema, doc, op)
 
 	props := map[string]any{}
 	required := []string{}
 
 	for _, v := range op.VariableDefinitions {
-		schema := map[string]any{
-			"type": graphqlTypeToJSONSchemaType(v.Type),
+		varSchema := builder.typeSchema(v.Type)
+		if description := descriptions[v.Variable]; description != "" {
+			varSchema = withDescription(varSchema, description)
 		}
-		props[v.Variable] = schema
-		if v.Type.NonNull {
+		varSchema = withDefault(varSchema, v.DefaultValue)
+		props[v.Variable] = varSchema
+		// Variables with a default may be omitted even when they are non-null
+		if v.Type.NonNull && v.DefaultValue == nil {
 			required = append(required, v.Variable)
 		}
 	}
@@ -37,9 +45,187 @@ func ExtractInputSchema(rawQuery string) (map[string]any, error) {
 	if len(required) > 0 {
 		result["required"] = required
 	}
+	if len(builder.defs) > 0 {
+		result["$defs"] = builder.defs
+	}
 	return result, nil
 }
 
+// schemaBuilder converts GraphQL input types to JSON schemas.
+// Input objects are expanded inline; a type that references itself is emitted once under `$defs`
+// and referenced with `$ref` from within its own definition.
+type schemaBuilder struct {
+	schema   *ast.Schema
+	scalars  map[string]map[string]any
+	defs     map[string]any
+	building map[string]bool // input objects currently being expanded
+	cyclic   map[string]bool // input objects referenced from within themselves
+}
+
+func newSchemaBuilder(schema *ast.Schema, scalars map[string]map[string]any) *schemaBuilder {
+	return &schemaBuilder{
+		schema:   schema,
+		scalars:  scalars,
+		defs:     map[string]any{},
+		building: map[string]bool{},
+		cyclic:   map[string]bool{},
+	}
+}
+
+// typeSchema returns the JSON schema of a GraphQL type, preserving list nesting; nullable types also accept null
+func (b *schemaBuilder) typeSchema(t *ast.Type) map[string]any {
+	var result map[string]any
+	if t.Elem != nil {
+		result = map[string]any{
+			"type":  "array",
+			"items": b.typeSchema(t.Elem),
+		}
+	} else {
+		result = b.namedTypeSchema(t.NamedType)
+	}
+	if !t.NonNull {
+		result = nullable(result)
+	}
+	return result
+}
+
+// nullable returns the schema extended to accept null
+func nullable(schema map[string]any) map[string]any {
+	if _, ok := schema["$ref"]; ok {
+		return map[string]any{
+			"anyOf": []any{schema, map[string]any{"type": "null"}},
+		}
+	}
+	switch typ := schema["type"].(type) {
+	case string:
+		schema["type"] = []string{typ, "null"}
+	case []any:
+		for _, t := range typ {
+			if t == "null" {
+				return schema
+			}
+		}
+		schema["type"] = append(append([]any{}, typ...), "null")
+	}
+	if values, ok := schema["enum"].([]any); ok {
+		schema["enum"] = append(values, nil)
+	}
+	return schema
+}
+
+func (b *schemaBuilder) namedTypeSchema(name string) map[string]any {
+	var def *ast.Definition
+	if b.schema != nil {
+		def = b.schema.Types[name]
+	}
+	if def == nil {
+		if result, ok := scalarSchema(b.scalars, name); ok {
+			return result
+		}
+		return map[string]any{"type": graphqlTypeToJSONSchemaType(&ast.Type{NamedType: name})}
+	}
+
+	var result map[string]any
+	switch def.Kind {
+	case ast.Enum:
+		values := make([]any, 0, len(def.EnumValues))
+		for _, value := range def.EnumValues {
+			values = append(values, value.Name)
+		}
+		result = map[string]any{
+			"type": "string",
+			"enum": values,
+		}
+	case ast.InputObject:
+		if _, defined := b.defs[name]; defined || b.building[name] {
+			b.cyclic[name] = true
+			return map[string]any{"$ref": "#/$defs/" + name}
+		}
+		result = b.inputObjectSchema(def)
+	default:
+		var ok bool
+		if result, ok = scalarSchema(b.scalars, name); !ok || def.BuiltIn {
+			result = map[string]any{"type": graphqlTypeToJSONSchemaType(&ast.Type{NamedType: name})}
+		}
+	}
+
+	if def.Description != "" && !def.BuiltIn {
+		result["description"] = def.Description
+	}
+	if b.cyclic[name] {
+		b.defs[name] = result
+		return map[string]any{"$ref": "#/$defs/" + name}
+	}
+	return result
+}
+
+func (b *schemaBuilder) inputObjectSchema(def *ast.Definition) map[string]any {
+	b.building[def.Name] = true
+	defer delete(b.building, def.Name)
+
+	props := map[string]any{}
+	required := []string{}
+	for _, field := range def.Fields {
+		fieldSchema := b.typeSchema(field.Type)
+		if field.Description != "" {
+			fieldSchema = withDescription(fieldSchema, field.Description)
+		}
+		props[field.Name] = withDefault(fieldSchema, field.DefaultValue)
+		if field.Type.NonNull && field.DefaultValue == nil {
+			required = append(required, field.Name)
+		}
+	}
+
+	result := map[string]any{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		result["required"] = required
+	}
+	return result
+}
+
+// withDescription returns a copy of the schema with the description set, leaving shared `$defs` untouched
+func withDescription(schema map[string]any, description string) map[string]any {
+	result := make(map[string]any, len(schema)+1)
+	for key, value := range schema {
+		result[key] = value
+	}
+	result["description"] = description
+	return result
+}
+
+// scalarSchema returns a copy of the JSON schema a custom scalar is mapped to
+func scalarSchema(scalars map[string]map[string]any, name string) (map[string]any, bool) {
+	schema, ok := scalars[name]
+	if !ok {
+		return nil, false
+	}
+	result := make(map[string]any, len(schema))
+	for key, value := range schema {
+		result[key] = value
+	}
+	return result, true
+}
+
+// withDefault returns a copy of the schema with the GraphQL default value set, if there is one
+func withDefault(schema map[string]any, value *ast.Value) map[string]any {
+	if value == nil {
+		return schema
+	}
+	defaultValue, err := value.Value(nil)
+	if err != nil {
+		return schema
+	}
+	result := make(map[string]any, len(schema)+1)
+	for key, value := range schema {
+		result[key] = value
+	}
+	result["default"] = defaultValue
+	return result
+}
+
 func graphqlTypeToJSONSchemaType(t *ast.Type) string {
 	if t.Elem != nil {
 		return "array"
@@ -57,3 +243,96 @@ func graphqlTypeToJSONSchemaType(t *ast.Type) string {
 		return "string" // fallback
 	}
 }
+
+// variableDescriptions maps each variable of the operation to the schema description
+// of the first argument or input field it is passed to
+func variableDescriptions(schema *ast.Schema, doc *ast.QueryDocument, op *ast.OperationDefinition) map[string]string {
+	w := &descriptionWalker{
+		schema:       schema,
+		doc:          doc,
+		fragments:    map[string]bool{},
+		descriptions: map[string]string{},
+	}
+	if schema == nil {
+		return w.descriptions
+	}
+
+	switch op.Operation {
+	case ast.Query:
+		w.selectionSet(schema.Query, op.SelectionSet)
+	case ast.Mutation:
+		w.selectionSet(schema.Mutation, op.SelectionSet)
+	case ast.Subscription:
+		w.selectionSet(schema.Subscription, op.SelectionSet)
+	}
+	return w.descriptions
+}
+
+type descriptionWalker struct {
+	schema       *ast.Schema
+	doc          *ast.QueryDocument
+	fragments    map[string]bool // fragments already walked
+	descriptions map[string]string
+}
+
+func (w *descriptionWalker) selectionSet(parent *ast.Definition, selectionSet ast.SelectionSet) {
+	for _, selection := range selectionSet {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			if parent == nil {
+				continue
+			}
+			def := parent.Fields.ForName(sel.Name)
+			if def == nil {
+				continue
+			}
+			for _, arg := range sel.Arguments {
+				if argDef := def.Arguments.ForName(arg.Name); argDef != nil {
+					w.value(arg.Value, argDef.Description, argDef.Type)
+				}
+			}
+			w.selectionSet(w.schema.Types[def.Type.Name()], sel.SelectionSet)
+		case *ast.InlineFragment:
+			typeDef := parent
+			if sel.TypeCondition != "" {
+				typeDef = w.schema.Types[sel.TypeCondition]
+			}
+			w.selectionSet(typeDef, sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if w.fragments[sel.Name] {
+				continue
+			}
+			w.fragments[sel.Name] = true
+			if fragment := w.doc.Fragments.ForName(sel.Name); fragment != nil {
+				w.selectionSet(w.schema.Types[fragment.TypeCondition], fragment.SelectionSet)
+			}
+		}
+	}
+}
+
+// value records the description of the argument or input field for the variables in the value
+func (w *descriptionWalker) value(value *ast.Value, description string, t *ast.Type) {
+	if value == nil {
+		return
+	}
+	switch value.Kind {
+	case ast.Variable:
+		if description != "" && w.descriptions[value.Raw] == "" {
+			w.descriptions[value.Raw] = description
+		}
+	case ast.ListValue:
+		for _, child := range value.Children {
+			w.value(child.Value, description, t)
+		}
+	case ast.ObjectValue:
+		def := w.schema.Types[t.Name()]
+		if def == nil {
+			return
+		}
+		for _, child := range value.Children {
+			if field := def.Fields.ForName(child.Name); field != nil {
+				w.value(child.Value, field.Description, field.Type)
+			}
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package tool
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const testSchemaSDL = `
+type Query {
+  films(filter: FilmFilter): [Film]
+}
+
+type Mutation {
+  addFilm(film: FilmInput!, matrix: [[Int!]]): Film
+}
+
+type Film {
+  title: String
+}
+
+"The input for a new film"
+input FilmInput {
+  "The title of the film"
+  title: String!
+  episode: Episode
+  rating: Float = 5.0
+}
+
+"Recursive filter"
+input FilmFilter {
+  title: String
+  and: [FilmFilter!]
+}
+
+enum Episode {
+  NEWHOPE
+  EMPIRE
+  JEDI
+}
+`
+
+func loadTestSchema(t *testing.T) *ast.Schema {
+	t.Helper()
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: testSchemaSDL})
+	if err != nil {
+		t.Fatalf("Failed to load test schema: %v", err)
+	}
+	return schema
+}
+
+// toJSON renders a schema as JSON for easy comparison
+func toJSON(t *testing.T, v any) string {
+	t.Helper()
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+	return string(out)
+}
+
+func TestExtractInputSchemaWithSchema(t *testing.T) {
+	schema := loadTestSchema(t)
+
+	inputSchema, err := ExtractInputSchema(`
+mutation AddFilm($film: FilmInput!, $matrix: [[Int!]]) {
+  addFilm(film: $film, matrix: $matrix) { title }
+}`, schema, nil)
+	if err != nil {
+		t.Fatalf("ExtractInputSchema returned an error: %v", err)
+	}
+
+	props := inputSchema["properties"].(map[string]any)
+
+	// Input objects are expanded with their required fields, enums, defaults and descriptions
+	expectedFilm := `{"description":"The input for a new film","properties":{` +
+		`"episode":{"enum":["NEWHOPE","EMPIRE","JEDI",null],"type":["string","null"]},` +
+		`"rating":{"default":5,"type":["number","null"]},` +
+		`"title":{"description":"The title of the film","type":"string"}},` +
+		`"required":["title"],"type":"object"}`
+	if got := toJSON(t, props["film"]); got != expectedFilm {
+		t.Errorf("Unexpected film schema:\n got: %s\nwant: %s", got, expectedFilm)
+	}
+
+	// List nesting and the nullability of each level are preserved
+	expectedMatrix := `{"items":{"items":{"type":"integer"},"type":["array","null"]},"type":["array","null"]}`
+	if got := toJSON(t, props["matrix"]); got != expectedMatrix {
+		t.Errorf("Unexpected matrix schema:\n got: %s\nwant: %s", got, expectedMatrix)
+	}
+
+	if got := toJSON(t, inputSchema["required"]); got != `["film"]` {
+		t.Errorf("Expected film to be required, got %s", got)
+	}
+}
+
+func TestExtractInputSchemaRecursiveInput(t *testing.T) {
+	schema := loadTestSchema(t)
+
+	inputSchema, err := ExtractInputSchema(`
+query Films($filter: FilmFilter, $other: FilmFilter) {
+  films(filter: $filter) { title }
+}`, schema, nil)
+	if err != nil {
+		t.Fatalf("ExtractInputSchema returned an error: %v", err)
+	}
+
+	props := inputSchema["properties"].(map[string]any)
+	expectedRef := `{"anyOf":[{"$ref":"#/$defs/FilmFilter"},{"type":"null"}]}`
+	if got := toJSON(t, props["filter"]); got != expectedRef {
+		t.Errorf("Expected filter to reference $defs, got %s", got)
+	}
+	if got := toJSON(t, props["other"]); got != expectedRef {
+		t.Errorf("Expected other to reference $defs, got %s", got)
+	}
+
+	defs := inputSchema["$defs"].(map[string]any)
+	expectedDef := `{"description":"Recursive filter","properties":{` +
+		`"and":{"items":{"$ref":"#/$defs/FilmFilter"},"type":["array","null"]},` +
+		`"title":{"type":["string","null"]}},"type":"object"}`
+	if got := toJSON(t, defs["FilmFilter"]); got != expectedDef {
+		t.Errorf("Unexpected FilmFilter definition:\n got: %s\nwant: %s", got, expectedDef)
+	}
+}
+
+func TestExtractInputSchemaWithoutSchema(t *testing.T) {
+	inputSchema, err := ExtractInputSchema(`query Films($filter: FilmFilter, $ids: [ID!]!) { films { title } }`, nil, nil)
+	if err != nil {
+		t.Fatalf("ExtractInputSchema returned an error: %v", err)
+	}
+
+	expected := `{"properties":{"filter":{"type":["string","null"]},"ids":{"items":{"type":"string"},"type":"array"}},` +
+		`"required":["ids"],"type":"object"}`
+	if got := toJSON(t, inputSchema); got != expected {
+		t.Errorf("Unexpected schema:\n got: %s\nwant: %s", got, expected)
+	}
+}
+
+func TestExtractInputSchemaArgumentDescriptions(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: `
+type Query {
+  film("The ID of the film" id: ID!): Film
+}
+
+type Film {
+  title: String
+  characters("Only return characters matching the filter" filter: CharacterFilter): [String]
+}
+
+input CharacterFilter {
+  "Case-insensitive part of the name"
+  name: String
+}
+`})
+	if gqlErr != nil {
+		t.Fatalf("Failed to load test schema: %v", gqlErr)
+	}
+
+	inputSchema, err := ExtractInputSchema(`
+query Film($id: ID!, $name: String) {
+  film(id: $id) { ...FilmFields }
+}
+
+fragment FilmFields on Film {
+  characters(filter: {name: $name})
+}`, schema, nil)
+	if err != nil {
+		t.Fatalf("ExtractInputSchema returned an error: %v", err)
+	}
+
+	// Variables passed as arguments or inside input objects, also through fragments, get the schema description
+	props := inputSchema["properties"].(map[string]any)
+	if got := toJSON(t, props["id"]); got != `{"description":"The ID of the film","type":"string"}` {
+		t.Errorf("Unexpected id schema: %s", got)
+	}
+	if got := toJSON(t, props["name"]); got != `{"description":"Case-insensitive part of the name","type":["string","null"]}` {
+		t.Errorf("Unexpected name schema: %s", got)
+	}
+}
+
+func TestExtractInputSchemaDefaults(t *testing.T) {
+	schema := loadTestSchema(t)
+
+	inputSchema, err := ExtractInputSchema(`
+mutation AddFilm($film: FilmInput! = {title: "A New Hope", episode: NEWHOPE}, $matrix: [[Int!]] = [[1, 2]]) {
+  addFilm(film: $film, matrix: $matrix) { title }
+}`, schema, nil)
+	if err != nil {
+		t.Fatalf("ExtractInputSchema returned an error: %v", err)
+	}
+
+	// Defaults are emitted, and non-null variables with a default are optional
+	props := inputSchema["properties"].(map[string]any)
+	if got := toJSON(t, props["film"].(map[string]any)["default"]); got != `{"episode":"NEWHOPE","title":"A New Hope"}` {
+		t.Errorf("Unexpected film default: %s", got)
+	}
+	if got := toJSON(t, props["matrix"].(map[string]any)["default"]); got != `[[1,2]]` {
+		t.Errorf("Unexpected matrix default: %s", got)
+	}
+	if required, ok := inputSchema["required"]; ok {
+		t.Errorf("Expected no required variables, got %v", required)
+	}
+}
+
+func TestSchemasWithCustomScalars(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: `
+scalar DateTime
+scalar Money
+scalar Opaque
+
+type Query {
+  film(releasedAfter: DateTime, budget: Money!, meta: Opaque): Film
+}
+
+type Film {
+  released: DateTime!
+  budget: Money
+}
+`})
+	if gqlErr != nil {
+		t.Fatalf("Failed to load test schema: %v", gqlErr)
+	}
+	scalars := map[string]map[string]any{
+		"DateTime": {"type": "string", "format": "date-time"},
+		"Money":    {"type": []any{"string", "number"}, "examples": []any{"12.50"}},
+	}
+	query := `
+query Film($releasedAfter: DateTime, $budget: Money!, $meta: Opaque) {
+  film(releasedAfter: $releasedAfter, budget: $budget, meta: $meta) { released budget }
+}`
+
+	inputSchema, err := ExtractInputSchema(query, schema, scalars)
+	if err != nil {
+		t.Fatalf("ExtractInputSchema returned an error: %v", err)
+	}
+	expectedInput := `{"properties":{` +
+		`"budget":{"examples":["12.50"],"type":["string","number"]},` +
+		`"meta":{"type":["string","null"]},` +
+		`"releasedAfter":{"format":"date-time","type":["string","null"]}},` +
+		`"required":["budget"],"type":"object"}`
+	if got := toJSON(t, inputSchema); got != expectedInput {
+		t.Errorf("Unexpected input schema:\n got: %s\nwant: %s", got, expectedInput)
+	}
+
+	outputSchema, err := ExtractOutputSchema(query, schema, scalars)
+	if err != nil {
+		t.Fatalf("ExtractOutputSchema returned an error: %v", err)
+	}
+	film := outputSchema["properties"].(map[string]any)["film"].(map[string]any)
+	expectedFilm := `{"properties":{` +
+		`"budget":{"examples":["12.50"],"type":["string","number","null"]},` +
+		`"released":{"format":"date-time","type":"string"}},` +
+		`"required":["released","budget"],"type":["object","null"]}`
+	if got := toJSON(t, film); got != expectedFilm {
+		t.Errorf("Unexpected film schema:\n got: %s\nwant: %s", got, expectedFilm)
+	}
+
+	// The mappings are copied, not modified
+	if got := toJSON(t, scalars["Money"]); got != `{"examples":["12.50"],"type":["string","number"]}` {
+		t.Errorf("Expected the scalar mapping to be left untouched, got %s", got)
+	}
+}
@@ -1,48 +1,96 @@
 package tool
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
+
 	"github.com/fotoetienne/gqai/graphql"
 )
 
-func ToolsFromConfig(config *graphql.GraphQLConfig) ([]*MCPTool, error) {
-	ops, err := graphql.LoadOperations(config)
+func ToolsFromConfig(ctx context.Context, config *graphql.GraphQLConfig) ([]*MCPTool, error) {
+	ops, err := graphql.LoadOperations(ctx, config)
 	if err != nil {
 		return nil, err
 	}
 
 	var tools []*MCPTool
-	for _, op := range ops {
-		tools = append(tools, toolFromOperation(config, op))
+	for name, op := range ops {
+		tools = append(tools, toolFromOperation(name, op, time.Time{}))
 	}
 	return tools, nil
 }
 
-func LoadTool(config *graphql.GraphQLConfig, name string) (*MCPTool, error) {
-	ops, err := graphql.LoadOperations(config)
+// ErrToolNotFound is returned by LoadTool when no operation has the tool's name
+var ErrToolNotFound = errors.New("tool not found")
+
+// LoadTool loads the tool to call. The call's timeout starts now, so that it covers loading the tool too:
+// the first load of a remote schema can wait for the introspection, up to the project's timeout.
+func LoadTool(ctx context.Context, config *graphql.GraphQLConfig, name string) (*MCPTool, error) {
+	started := time.Now()
+	ops, err := graphql.LoadOperations(ctx, config)
 	if err != nil {
 		return nil, err
 	}
 
 	op := ops[name]
 	if op != nil {
-		return toolFromOperation(config, op), nil
+		return toolFromOperation(name, op, started), nil
 	}
 
-	return nil, fmt.Errorf("tool %s not found", name)
+	return nil, fmt.Errorf("%w: %s", ErrToolNotFound, name)
 }
 
-func toolFromOperation(config *graphql.GraphQLConfig, op *graphql.Operation) *MCPTool {
-	inputSchema, _ := ExtractInputSchema(op.Raw)
-	endpoint := config.SingleProject.Schema[0].URL
-	headers := config.SingleProject.Schema[0].Headers
+// toolFromOperation builds the tool for an operation, routing calls to the endpoint of the operation's project.
+// Calls time out the operation's timeout after started, or after they start when it is zero.
+func toolFromOperation(name string, op *graphql.Operation, started time.Time) *MCPTool {
+	scalars := op.Project.Scalars()
+	inputSchema, _ := ExtractInputSchema(op.Query, op.Schema, scalars)
+	if props, ok := inputSchema["properties"].(map[string]any); ok {
+		for variable, description := range op.Metadata.Arguments {
+			if prop, ok := props[variable].(map[string]any); ok {
+				props[variable] = withDescription(prop, description)
+			}
+		}
+	}
+
+	outputSchema, _ := ExtractOutputSchema(op.Query, op.Schema, scalars)
+
+	title := op.Name
+	if op.Metadata.Title != "" {
+		title = op.Metadata.Title
+	}
+
+	endpoint, headers := op.Project.Endpoint()
 
 	return &MCPTool{
-		Name:        op.Name,
-		Description: "", // TODO: maybe use docstring/comments?
-		InputSchema: inputSchema,
-		Execute: func(input map[string]any) (any, error) {
-			return graphql.Execute(endpoint, input, op, headers)
+		Name:         name,
+		Description:  op.Description,
+		InputSchema:  inputSchema,
+		OutputSchema: outputSchema,
+		Execute: func(ctx context.Context, input map[string]any) (any, error) {
+			if endpoint == "" {
+				return nil, fmt.Errorf("project %s has no endpoint: add a schema URL or extensions.gqai.endpoint", op.Project.Name)
+			}
+
+			variables, err := graphql.CoerceVariables(op, input)
+			if err != nil {
+				return nil, err
+			}
+
+			start := started
+			if start.IsZero() {
+				start = time.Now()
+			}
+			ctx, cancel := context.WithDeadline(ctx, start.Add(op.Timeout()))
+			defer cancel()
+
+			result, err := graphql.Execute(ctx, endpoint, variables, op, headers)
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%s timed out after %s", name, op.Timeout())
+			}
+			return result, err
 		},
 		Annotations: struct {
 			Title           string `json:"title,omitempty"`
@@ -51,11 +99,19 @@ func toolFromOperation(config *graphql.GraphQLConfig, op *graphql.Operation) *MC
 			IdempotentHint  bool   `json:"idempotentHint"`
 			OpenWorldHint   bool   `json:"openWorldHint"`
 		}{
-			Title:           op.Name,
-			ReadOnlyHint:    op.OperationType == "query",
-			DestructiveHint: op.OperationType == "mutation",
-			IdempotentHint:  op.OperationType == "query",
-			OpenWorldHint:   true,
+			Title:           title,
+			ReadOnlyHint:    hint(op.Metadata.ReadOnly, op.OperationType == "query"),
+			DestructiveHint: hint(op.Metadata.Destructive, op.OperationType == "mutation"),
+			IdempotentHint:  hint(op.Metadata.Idempotent, op.OperationType == "query"),
+			OpenWorldHint:   hint(op.Metadata.OpenWorld, true),
 		},
 	}
 }
+
+// hint returns the annotation set in the operation's metadata, or the default derived from the operation
+func hint(value *bool, fallback bool) bool {
+	if value != nil {
+		return *value
+	}
+	return fallback
+}
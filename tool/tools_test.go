@@ -1,9 +1,17 @@
 package tool
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/fotoetienne/gqai/graphql"
 )
@@ -59,7 +67,7 @@ mutation AddFilm($film: FilmInput!) {
 	}
 
 	// Get tools from config
-	tools, err := ToolsFromConfig(config)
+	tools, err := ToolsFromConfig(context.Background(), config)
 	if err != nil {
 		t.Fatalf("ToolsFromConfig returned an error: %v", err)
 	}
@@ -138,7 +146,7 @@ query GetFilm($id: ID!) {
 	}
 
 	// Load a specific tool
-	tool, err := LoadTool(config, "GetFilm")
+	tool, err := LoadTool(context.Background(), config, "GetFilm")
 	if err != nil {
 		t.Fatalf("LoadTool returned an error: %v", err)
 	}
@@ -152,8 +160,299 @@ query GetFilm($id: ID!) {
 	}
 
 	// Test loading a non-existent tool
-	_, err = LoadTool(config, "NonExistentTool")
-	if err == nil {
-		t.Error("Expected error when loading non-existent tool, got nil")
+	_, err = LoadTool(context.Background(), config, "NonExistentTool")
+	if !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound when loading non-existent tool, got %v", err)
+	}
+}
+
+func TestToolsFromConfigWithProjects(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Each project gets its own backend and its own operations
+	var servers = map[string]*httptest.Server{}
+	for _, name := range []string{"films", "people"} {
+		name := name
+		servers[name] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Project") != name {
+				t.Errorf("Expected X-Project header to be %s, got '%s'", name, r.Header.Get("X-Project"))
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"data": {"project": %q}}`, name)
+		}))
+		defer servers[name].Close()
+
+		dir := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create temporary operations directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "get.graphql"), []byte("query Get { project }"), 0644); err != nil {
+			t.Fatalf("Failed to create sample GraphQL file: %v", err)
+		}
+	}
+
+	config := &graphql.GraphQLConfig{
+		MultiProjects: &graphql.GraphQLProjects{
+			Projects: map[string]*graphql.GraphQLProject{},
+		},
+	}
+	for name, server := range servers {
+		config.MultiProjects.Projects[name] = &graphql.GraphQLProject{
+			Schema: []graphql.SchemaPointer{
+				{URL: server.URL, Headers: map[string]string{"X-Project": name}},
+			},
+			Documents: []string{filepath.Join(tempDir, name)},
+		}
+	}
+
+	tools, err := ToolsFromConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("ToolsFromConfig returned an error: %v", err)
+	}
+	if len(tools) != 2 {
+		t.Fatalf("Expected 2 tools, got %d", len(tools))
+	}
+
+	// Tool names are namespaced by project and calls are routed to the project's endpoint
+	for _, name := range []string{"films", "people"} {
+		tool, err := LoadTool(context.Background(), config, name+"_Get")
+		if err != nil {
+			t.Fatalf("LoadTool returned an error: %v", err)
+		}
+		result, err := tool.Execute(context.Background(), map[string]any{})
+		if err != nil {
+			t.Fatalf("Execute returned an error: %v", err)
+		}
+		data := result.(map[string]any)["data"].(map[string]any)
+		if data["project"] != name {
+			t.Fatalf("Expected call to be routed to %s, got %v", name, data["project"])
+		}
+	}
+
+	// Narrowing to one project drops the namespace
+	selected, err := config.SelectProject("people")
+	if err != nil {
+		t.Fatalf("SelectProject returned an error: %v", err)
+	}
+	if _, err := LoadTool(context.Background(), selected, "Get"); err != nil {
+		t.Fatalf("Expected un-namespaced tool for a single project, got %v", err)
+	}
+}
+
+func TestToolsFromConfigWithSchemaFile(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// The backend only executes operations; the types come from a local SDL file
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Expected Authorization header to be 'Bearer token', got '%s'", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data": {"addFilm": {"title": "A New Hope"}}}`)
+	}))
+	defer server.Close()
+
+	schemaPath := filepath.Join(tempDir, "schema.graphql")
+	if err := os.WriteFile(schemaPath, []byte(testSchemaSDL), 0644); err != nil {
+		t.Fatalf("Failed to create schema file: %v", err)
+	}
+	operationsDir := filepath.Join(tempDir, "operations")
+	if err := os.MkdirAll(operationsDir, 0755); err != nil {
+		t.Fatalf("Failed to create temporary operations directory: %v", err)
+	}
+	mutation := "# Add a film to the catalog\nmutation AddFilm($film: FilmInput!) { addFilm(film: $film) { title } }"
+	if err := os.WriteFile(filepath.Join(operationsDir, "add_film.graphql"), []byte(mutation), 0644); err != nil {
+		t.Fatalf("Failed to create sample GraphQL file: %v", err)
+	}
+
+	config := &graphql.GraphQLConfig{
+		SingleProject: &graphql.GraphQLProject{
+			Schema:    []graphql.SchemaPointer{{URL: schemaPath}},
+			Documents: []string{operationsDir},
+			Options: graphql.ProjectOptions{
+				Endpoint: server.URL,
+				Headers:  map[string]string{"Authorization": "Bearer token"},
+			},
+		},
+	}
+
+	tool, err := LoadTool(context.Background(), config, "AddFilm")
+	if err != nil {
+		t.Fatalf("LoadTool returned an error: %v", err)
+	}
+
+	if tool.Description != "Add a film to the catalog" {
+		t.Errorf("Expected the description to come from the comment, got %q", tool.Description)
+	}
+
+	film := tool.InputSchema["properties"].(map[string]any)["film"].(map[string]any)
+	if film["type"] != "object" {
+		t.Fatalf("Expected film input to be typed from the schema file, got %v", film)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"film": map[string]any{"title": "A New Hope"}}); err != nil {
+		t.Fatalf("Execute returned an error: %v", err)
+	}
+}
+
+func TestToolsFromConfigWithMetadata(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// The backend must never see the client-side @mcp directives
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		query = body.Query
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data": {"addFilm": {"title": "A New Hope"}}}`)
+	}))
+	defer server.Close()
+
+	schemaPath := filepath.Join(tempDir, "schema.graphql")
+	if err := os.WriteFile(schemaPath, []byte(testSchemaSDL), 0644); err != nil {
+		t.Fatalf("Failed to create schema file: %v", err)
+	}
+	operationsDir := filepath.Join(tempDir, "operations")
+	if err := os.MkdirAll(operationsDir, 0755); err != nil {
+		t.Fatalf("Failed to create temporary operations directory: %v", err)
+	}
+	mutation := `# Add a film to the catalog
+# @title Add film
+# @idempotent true
+# @arg film The film to add
+mutation AddFilm($film: FilmInput!, $matrix: [[Int!]] @mcp(description: "Unused matrix"))
+  @mcp(name: "add_film", destructive: false) {
+  addFilm(film: $film, matrix: $matrix) { title }
+}
+`
+	if err := os.WriteFile(filepath.Join(operationsDir, "add_film.graphql"), []byte(mutation), 0644); err != nil {
+		t.Fatalf("Failed to create sample GraphQL file: %v", err)
+	}
+
+	config := &graphql.GraphQLConfig{
+		SingleProject: &graphql.GraphQLProject{
+			Schema:    []graphql.SchemaPointer{{URL: schemaPath}},
+			Documents: []string{operationsDir},
+			Options: graphql.ProjectOptions{
+				Endpoint:   server.URL,
+				Validation: graphql.ValidationStrict,
+			},
+		},
+	}
+
+	tool, err := LoadTool(context.Background(), config, "add_film")
+	if err != nil {
+		t.Fatalf("LoadTool returned an error: %v", err)
+	}
+
+	if tool.Description != "Add a film to the catalog" {
+		t.Errorf("Unexpected description %q", tool.Description)
+	}
+	annotations := tool.Annotations
+	if annotations.Title != "Add film" || annotations.ReadOnlyHint || annotations.DestructiveHint ||
+		!annotations.IdempotentHint || !annotations.OpenWorldHint {
+		t.Errorf("Unexpected annotations: %+v", annotations)
+	}
+
+	props := tool.InputSchema["properties"].(map[string]any)
+	if description := props["film"].(map[string]any)["description"]; description != "The film to add" {
+		t.Errorf("Expected film description from the comment tag, got %v", description)
+	}
+	if description := props["matrix"].(map[string]any)["description"]; description != "Unused matrix" {
+		t.Errorf("Expected matrix description from the directive, got %v", description)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"film": map[string]any{"title": "A New Hope"}}); err != nil {
+		t.Fatalf("Execute returned an error: %v", err)
+	}
+	if strings.Contains(query, "@mcp") || !strings.Contains(query, "mutation AddFilm") {
+		t.Errorf("Expected the @mcp directives to be stripped, got:\n%s", query)
+	}
+}
+
+func TestToolTimeout(t *testing.T) {
+	// The backend hangs until the test ends
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	tempDir := t.TempDir()
+	query := "# @timeout 50ms\nquery Slow { slow }"
+	if err := os.WriteFile(filepath.Join(tempDir, "slow.graphql"), []byte(query), 0644); err != nil {
+		t.Fatalf("Failed to create sample GraphQL file: %v", err)
+	}
+
+	config := &graphql.GraphQLConfig{
+		SingleProject: &graphql.GraphQLProject{
+			Documents: []string{tempDir},
+			Options: graphql.ProjectOptions{
+				Endpoint: server.URL,
+				Timeout:  time.Minute,
+			},
+		},
+	}
+
+	tool, err := LoadTool(context.Background(), config, "Slow")
+	if err != nil {
+		t.Fatalf("LoadTool returned an error: %v", err)
+	}
+
+	// The operation's timeout overrides the project's
+	start := time.Now()
+	_, err = tool.Execute(context.Background(), map[string]any{})
+	if err == nil || !strings.Contains(err.Error(), "Slow timed out after 50ms") {
+		t.Fatalf("Expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the call to be aborted after 50ms, took %s", elapsed)
+	}
+}
+
+func TestToolTimeoutWithRemoteSchema(t *testing.T) {
+	// The backend accepts connections but never answers, introspection included
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "slow.graphql"), []byte("query Slow { slow }"), 0644); err != nil {
+		t.Fatalf("Failed to create sample GraphQL file: %v", err)
+	}
+
+	timeout := 300 * time.Millisecond
+	config := &graphql.GraphQLConfig{
+		SingleProject: &graphql.GraphQLProject{
+			Schema:    []graphql.SchemaPointer{{URL: server.URL}},
+			Documents: []string{tempDir},
+			Options: graphql.ProjectOptions{
+				Timeout:       timeout,
+				Introspection: graphql.IntrospectionOptions{CacheDir: t.TempDir()},
+			},
+		},
+	}
+
+	// Waiting for the introspection counts towards the call's timeout
+	start := time.Now()
+	tool, err := LoadTool(context.Background(), config, "Slow")
+	if err != nil {
+		t.Fatalf("LoadTool returned an error: %v", err)
+	}
+	_, err = tool.Execute(context.Background(), map[string]any{})
+	if err == nil || !strings.Contains(err.Error(), "Slow timed out after 300ms") {
+		t.Fatalf("Expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > timeout+timeout/2 {
+		t.Errorf("Expected the whole call to be aborted after %s, took %s", timeout, elapsed)
 	}
 }